@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 
 	"genproto/catalog_service"
+	"genproto/common"
 	"net"
 	"os"
 	"os/signal"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/Invan2/invan_catalog_service/config"
 	"github.com/Invan2/invan_catalog_service/events"
+	"github.com/Invan2/invan_catalog_service/models"
 	"github.com/Invan2/invan_catalog_service/pkg/logger"
 	"github.com/Invan2/invan_catalog_service/services/listeners"
 	"github.com/Invan2/invan_catalog_service/storage"
@@ -79,6 +82,12 @@ func main() {
 	defer psqlConn.Close()
 
 	elastic := storage.NewStorageES(log, eClient, cfg)
+
+	if err := elastic.ProductIndex().EnsureTemplate(); err != nil {
+		log.Error("elastic template", logger.Error(err))
+		return
+	}
+
 	storage := storage.NewStoragePg(log, psqlConn, cfg)
 
 	conf := kafka.ConfigMap{
@@ -106,7 +115,15 @@ func main() {
 		return
 	}
 
-	consumer, err := kafka.NewConsumer(&conf)
+	// offsets are committed by pubSubServer after the event is handled
+	consumerConf := kafka.ConfigMap{"enable.auto.commit": false}
+	for key, value := range conf {
+		if _, ok := consumerConf[key]; !ok {
+			consumerConf[key] = value
+		}
+	}
+
+	consumer, err := kafka.NewConsumer(&consumerConf)
 	if err != nil {
 		log.Error("error while creating consumer", logger.Error(err))
 		return
@@ -121,15 +138,35 @@ func main() {
 		return
 	}
 
-	pubsubServer, err := events.NewPubSubServer(log, producer, consumer, storage, elastic)
+	pubsubServer, err := events.NewPubSubServer(log, cfg, producer, consumer, storage, elastic)
 	if err != nil {
 		log.Fatal("error creating pubSubServer", logger.Error(err))
 		return
 	}
 
+	catalogService := listeners.NewCatalogService(log, pubsubServer, storage, elastic, minioClient, &cfg)
+
+	if len(os.Args) > 1 {
+		cmdCtx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+		defer stop()
+
+		if err := runCommand(cmdCtx, log, catalogService, storage, os.Args[1:]); err != nil {
+			log.Error("error while running command", logger.String("command", os.Args[1]), logger.Error(err))
+		}
+
+		producer.Flush(int(cfg.KafkaDeliveryTimeout.Milliseconds()))
+		return
+	}
+
+	// nested shop queries fail on indices built before them, reindex must run before the deploy
+	if err := elastic.ProductIndex().CheckMapping(config.ElasticProductIndex); err != nil {
+		log.Error("elastic mapping", logger.Error(err))
+		return
+	}
+
 	server := grpc.NewServer()
 
-	catalog_service.RegisterCatalogServiceServer(server, listeners.NewCatalogService(log, pubsubServer, storage, elastic, minioClient, &cfg))
+	catalog_service.RegisterCatalogServiceServer(server, catalogService)
 
 	lis, err := net.Listen("tcp", fmt.Sprintf("%s%s", cfg.HttpHost, cfg.HttpPort))
 	if err != nil {
@@ -151,6 +188,8 @@ func main() {
 		log.Info("server stopped gracefully")
 	}()
 
+	go catalogService.RunJobs(ctx)
+
 	go func() {
 		if err := pubsubServer.Run(ctx); err != nil {
 			log.Error("error while start pub sub server", logger.Error(err))
@@ -162,3 +201,89 @@ func main() {
 		return
 	}
 }
+
+// runCommand runs admin commands, e.g. "replay -company <id> [-shop <id>] [-since <date time>] [-rate 100]"
+func runCommand(ctx context.Context, log logger.Logger, service listeners.CatalogService, strg storage.StoragePg, args []string) error {
+	switch args[0] {
+	case "replay":
+		var (
+			req  = catalog_service.ReplayCatalogRequest{Request: &common.Request{}}
+			rate int
+		)
+
+		flags := flag.NewFlagSet("replay", flag.ExitOnError)
+		flags.StringVar(&req.Request.CompanyId, "company", "", "company id")
+		flags.StringVar(&req.ShopId, "shop", "", "replay only products of the shop")
+		flags.StringVar(&req.UpdatedSince, "since", "", "replay only products updated since, "+config.DateTimeFormat)
+		flags.IntVar(&rate, "rate", 0, "products per second")
+
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		req.RatePerSecond = int32(rate)
+
+		res, err := service.ReplayCatalog(ctx, &req)
+		if err != nil {
+			return err
+		}
+
+		log.Info("catalog replayed", logger.Any("result", res))
+	case "reindex":
+		var req models.ReindexProductsRequest
+
+		flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+		flags.StringVar(&req.CompanyId, "company", "", "rebuild only products of the company on the live index")
+		flags.BoolVar(&req.KeepOldIndex, "keep-old", false, "keep the previous index after switching the alias")
+
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		res, err := service.ReindexProducts(ctx, &req)
+		if err != nil {
+			return err
+		}
+
+		log.Info("products reindexed", logger.Any("result", res))
+	case "check":
+		var (
+			companyId string
+			repair    bool
+			companies []string
+		)
+
+		flags := flag.NewFlagSet("check", flag.ExitOnError)
+		flags.StringVar(&companyId, "company", "", "check only the company")
+		flags.BoolVar(&repair, "repair", false, "rewrite mismatched products on elastic from postgres")
+
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		companies = []string{companyId}
+		if companyId == "" {
+			ids, err := strg.Company().GetIds()
+			if err != nil {
+				return err
+			}
+
+			companies = ids
+		}
+
+		for _, companyId := range companies {
+			res, err := service.CheckConsistency(ctx, &catalog_service.CheckConsistencyRequest{Request: &common.Request{CompanyId: companyId}, Repair: repair})
+			if err != nil {
+				return err
+			}
+
+			if len(res.Mismatches) > 0 {
+				log.Warn("products are inconsistent", logger.Any("result", res))
+			}
+		}
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+
+	return nil
+}
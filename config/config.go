@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
@@ -12,7 +13,33 @@ type Config struct {
 	Environment string
 	ServiceName string
 
-	KafkaUrl         string
+	KafkaUrl                 string
+	KafkaRetryMaxAttempts    int
+	KafkaRetryInitialBackoff time.Duration
+	KafkaRetryMaxBackoff     time.Duration
+	// topic -> max attempts, overrides KafkaRetryMaxAttempts
+	KafkaTopicRetryAttempts map[string]int
+	// attempts to save the failed event to dead_letter table when it is already in dead letter topic
+	KafkaDeadLetterAttempts int
+	// number of workers handling events, events with the same key are handled by one worker
+	KafkaWorkers int
+	// polling stops when this number of events are received but not handled yet
+	KafkaMaxInFlight     int
+	KafkaWorkerQueueSize int
+
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	// how long publishing waits for the broker acknowledgement
+	KafkaDeliveryTimeout time.Duration
+
+	JobWorkers      int
+	JobPollInterval time.Duration
+	// running job without heartbeat for this long is taken by another worker
+	JobStaleTimeout time.Duration
+
+	// default throttling of catalog replay
+	ReplayRatePerSecond int
+
 	MinioAccessKeyID string
 	MinioSecretKey   string
 	MinioEndpoint    string
@@ -59,6 +86,24 @@ func Load() Config {
 	config.ElasticSearchPassword = cast.ToString(getOrReturnDefault("ELASTIC_SEARCH_PASSWORD", "changeme"))
 
 	config.KafkaUrl = cast.ToString(getOrReturnDefault("KAFKA_URL", "localhost:9092"))
+	config.KafkaRetryMaxAttempts = cast.ToInt(getOrReturnDefault("KAFKA_RETRY_MAX_ATTEMPTS", 5))
+	config.KafkaRetryInitialBackoff = time.Duration(cast.ToInt(getOrReturnDefault("KAFKA_RETRY_INITIAL_BACKOFF_MS", 500))) * time.Millisecond
+	config.KafkaRetryMaxBackoff = time.Duration(cast.ToInt(getOrReturnDefault("KAFKA_RETRY_MAX_BACKOFF_MS", 30000))) * time.Millisecond
+	config.KafkaTopicRetryAttempts = parseTopicRetryAttempts(cast.ToString(getOrReturnDefault("KAFKA_TOPIC_RETRY_ATTEMPTS", "")))
+	config.KafkaDeadLetterAttempts = cast.ToInt(getOrReturnDefault("KAFKA_DEAD_LETTER_ATTEMPTS", 5))
+	config.KafkaWorkers = cast.ToInt(getOrReturnDefault("KAFKA_WORKERS", 16))
+	config.KafkaMaxInFlight = cast.ToInt(getOrReturnDefault("KAFKA_MAX_IN_FLIGHT", 1000))
+	config.KafkaWorkerQueueSize = cast.ToInt(getOrReturnDefault("KAFKA_WORKER_QUEUE_SIZE", 100))
+
+	config.OutboxPollInterval = time.Duration(cast.ToInt(getOrReturnDefault("OUTBOX_POLL_INTERVAL_MS", 500))) * time.Millisecond
+	config.OutboxBatchSize = cast.ToInt(getOrReturnDefault("OUTBOX_BATCH_SIZE", 100))
+	config.KafkaDeliveryTimeout = time.Duration(cast.ToInt(getOrReturnDefault("KAFKA_DELIVERY_TIMEOUT_MS", 10000))) * time.Millisecond
+
+	config.JobWorkers = cast.ToInt(getOrReturnDefault("JOB_WORKERS", 4))
+	config.JobPollInterval = time.Duration(cast.ToInt(getOrReturnDefault("JOB_POLL_INTERVAL_MS", 1000))) * time.Millisecond
+	config.JobStaleTimeout = time.Duration(cast.ToInt(getOrReturnDefault("JOB_STALE_TIMEOUT_MS", 300000))) * time.Millisecond
+
+	config.ReplayRatePerSecond = cast.ToInt(getOrReturnDefault("REPLAY_RATE_PER_SECOND", 100))
 
 	config.HttpPort = cast.ToString(getOrReturnDefault("GRPC_PORT", ":8008"))
 	config.HttpHost = cast.ToString(getOrReturnDefault("LISTEN_HOST", "localhost"))
@@ -75,3 +120,19 @@ func getOrReturnDefault(key string, defaultValue interface{}) interface{} {
 
 	return defaultValue
 }
+
+// parseTopicRetryAttempts parses "topic1=3,topic2=10"
+func parseTopicRetryAttempts(value string) map[string]int {
+	res := make(map[string]int)
+
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			continue
+		}
+
+		res[kv[0]] = cast.ToInt(kv[1])
+	}
+
+	return res
+}
@@ -28,6 +28,31 @@ const (
 	ReleaseMode = "release"
 
 	FileBucketName = "file"
+
+	// EventSource is ce_source of published events if SERVICE_NAME is not set
+	EventSource = "catalog_service"
+
+	// stock movement types
+	StockMovementOrderFinished = "order_finished"
+	StockMovementOrderReturned = "order_returned"
+	StockMovementTransferSent  = "transfer_sent"
+	StockMovementTransferIn    = "transfer_arrived"
+	StockMovementWriteOff      = "write_off"
+	StockMovementImport        = "import"
+
+	// job types
+	JobProductExcelExport = "product_excel_export"
+	JobProductCsvExport   = "product_csv_export"
+	JobProductLabels      = "product_labels"
+	JobScalesFile         = "scales_file"
+	JobProductBulkUpdate  = "product_bulk_update"
+
+	// job statuses
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
 )
 
 var (
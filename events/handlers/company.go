@@ -23,7 +23,29 @@ func (e *EventHandler) CreateCompany(ctx context.Context, event *kafka.Message)
 
 	// e.log.info("create company event", logger.Any("event", req))
 
-	if err := e.strgPG.Company().Upsert(&req); err != nil {
+	if req.Shop == nil {
+		return errors.New("error while create copmany shop. shop == nil")
+	}
+
+	tr, err := e.strgPG.WithTransaction()
+	if err != nil {
+		return errors.Wrap(err, "error while run transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tr.Rollback()
+		} else {
+			_ = tr.Commit()
+		}
+	}()
+
+	processed, err := e.markProcessed(tr, event)
+	if err != nil || !processed {
+		return err
+	}
+
+	if err = tr.Company().Upsert(&req); err != nil {
 		// e.log.info(err.Error(), logger.Any("event", req))
 
 		return err
@@ -35,7 +57,7 @@ func (e *EventHandler) CreateCompany(ctx context.Context, event *kafka.Message)
 
 	// e.log.info("getting measurementUnits")
 
-	measurement_units, err := e.strgPG.MeasurementUnit().GetAll(&catalog_service.GetAllMeasurementUnitsRequest{Limit: 10, Page: 1, Request: userReq})
+	measurement_units, err := tr.MeasurementUnit().GetAll(&catalog_service.GetAllMeasurementUnitsRequest{Limit: 10, Page: 1, Request: userReq})
 	if err != nil {
 		// e.log.info("error measurement_units getAll", logger.Error(err))
 		return err
@@ -56,20 +78,17 @@ func (e *EventHandler) CreateCompany(ctx context.Context, event *kafka.Message)
 		})
 	}
 
-	if err := e.Push("v1.catalog_service.measurement_units.created.success", common.MeasurementUnitsCopyRequest{
+	_, err = tr.Outbox().Create("v1.catalog_service.measurement_units.created.success", req.Id, &common.MeasurementUnitsCopyRequest{
 		MeasurementUnits: kafka_measurement_units,
 		Request:          userReq,
-	}); err != nil {
+	})
+	if err != nil {
 		return err
 	}
 
 	// e.log.info("company shop is about to create", logger.Any("event", req))
 
-	if req.Shop == nil {
-		return errors.New("error while create copmany shop. shop == nil")
-	}
-
-	if err := e.strgPG.Shop().Upsert(req.Shop); err != nil {
+	if err = tr.Shop().Upsert(req.Shop); err != nil {
 		return err
 	}
 
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"genproto/inventory_service"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/pkg/errors"
+)
+
+func (e *EventHandler) TransferSent(ctx context.Context, event *kafka.Message) error {
+
+	var (
+		req    inventory_service.TransferStockModel
+		deltas = make([]*models.ShopStockDelta, 0)
+	)
+
+	if err := json.Unmarshal(event.Value, &req); err != nil {
+		return errors.Wrap(err, "error while unmarshal req")
+	}
+
+	if req.TransferId == "" || req.DepartureShopId == "" {
+		return errors.New("transfer_id and departure_shop_id are required")
+	}
+
+	for _, item := range req.Items {
+		deltas = append(deltas, &models.ShopStockDelta{
+			ProductId:       item.ProductId,
+			ShopId:          req.DepartureShopId,
+			Amount:          -float64(item.Amount),
+			TotalTransfered: float64(item.Amount),
+		})
+	}
+
+	return e.applyStockMovement(event, req.TransferId, config.StockMovementTransferSent, deltas)
+}
+
+func (e *EventHandler) TransferArrived(ctx context.Context, event *kafka.Message) error {
+
+	var (
+		req    inventory_service.TransferStockModel
+		deltas = make([]*models.ShopStockDelta, 0)
+	)
+
+	if err := json.Unmarshal(event.Value, &req); err != nil {
+		return errors.Wrap(err, "error while unmarshal req")
+	}
+
+	if req.TransferId == "" || req.ArrivalShopId == "" {
+		return errors.New("transfer_id and arrival_shop_id are required")
+	}
+
+	for _, item := range req.Items {
+		deltas = append(deltas, &models.ShopStockDelta{
+			ProductId:            item.ProductId,
+			ShopId:               req.ArrivalShopId,
+			Amount:               float64(item.Amount),
+			TotalTransferArrived: float64(item.Amount),
+		})
+	}
+
+	return e.applyStockMovement(event, req.TransferId, config.StockMovementTransferIn, deltas)
+}
+
+func (e *EventHandler) WriteOffFinished(ctx context.Context, event *kafka.Message) error {
+
+	var (
+		req    inventory_service.WriteOffStockModel
+		deltas = make([]*models.ShopStockDelta, 0)
+	)
+
+	if err := json.Unmarshal(event.Value, &req); err != nil {
+		return errors.Wrap(err, "error while unmarshal req")
+	}
+
+	if req.WriteOffId == "" || req.ShopId == "" {
+		return errors.New("write_off_id and shop_id are required")
+	}
+
+	for _, item := range req.Items {
+		deltas = append(deltas, &models.ShopStockDelta{
+			ProductId: item.ProductId,
+			ShopId:    req.ShopId,
+			Amount:    -float64(item.Amount),
+		})
+	}
+
+	return e.applyStockMovement(event, req.WriteOffId, config.StockMovementWriteOff, deltas)
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"time"
 
+	"github.com/Invan2/invan_catalog_service/pkg/helper"
 	"github.com/Invan2/invan_catalog_service/pkg/logger"
 	"github.com/Invan2/invan_catalog_service/storage"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
@@ -13,32 +16,33 @@ type EventHandler struct {
 	strgPG   storage.StoragePg
 	strgES   storage.StorageES
 	producer *kafka.Producer
+	source   string
+	timeout  time.Duration
 }
 
-func NewHandler(log logger.Logger, strgPG storage.StoragePg, strgES storage.StorageES, producer *kafka.Producer) *EventHandler {
+func NewHandler(log logger.Logger, strgPG storage.StoragePg, strgES storage.StorageES, producer *kafka.Producer, source string, deliveryTimeout time.Duration) *EventHandler {
 	return &EventHandler{
 		log:      log,
 		strgPG:   strgPG,
 		strgES:   strgES,
 		producer: producer,
+		source:   source,
+		timeout:  deliveryTimeout,
 	}
 }
 
-func (h *EventHandler) Push(topic string, value interface{}) error {
+// Push publishes the value with trace id of the event being handled
+func (h *EventHandler) Push(ctx context.Context, topic string, key string, value interface{}) error {
 
 	data, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
 
-	deliveryChan := make(chan kafka.Event, 10000)
-	err = h.producer.Produce(&kafka.Message{
-		TopicPartition: kafka.TopicPartition{
-			Topic:     &topic,
-			Partition: kafka.PartitionAny,
-		},
-		Value: data,
-	}, deliveryChan)
+	err = helper.ProduceSync(h.producer, helper.NewKafkaMessage(topic, key, data, helper.EventHeaders{
+		Source:  h.source,
+		TraceId: helper.TraceIdFromContext(ctx),
+	}), h.timeout)
 	if err != nil {
 		return err
 	}
@@ -46,3 +50,25 @@ func (h *EventHandler) Push(topic string, value interface{}) error {
 	return nil
 
 }
+
+// markProcessed records the event in the transaction and returns false if it was already processed
+func (h *EventHandler) markProcessed(tr storage.StorageTrI, event *kafka.Message) (bool, error) {
+
+	var topic string
+	if event.TopicPartition.Topic != nil {
+		topic = *event.TopicPartition.Topic
+	}
+
+	id := helper.KafkaMessageID(event)
+
+	ok, err := tr.ProcessedEvent().MarkProcessed(id, topic)
+	if err != nil {
+		return false, err
+	}
+
+	if !ok {
+		h.log.Info("event already processed", logger.String("id", id), logger.String("topic", topic))
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"genproto/common"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/pkg/errors"
+)
+
+func (e *EventHandler) OrderFinished(ctx context.Context, event *kafka.Message) error {
+	return e.applyOrder(event, config.StockMovementOrderFinished, -1)
+}
+
+func (e *EventHandler) OrderReturned(ctx context.Context, event *kafka.Message) error {
+	return e.applyOrder(event, config.StockMovementOrderReturned, 1)
+}
+
+// applyOrder moves sold (sign = -1) or returned (sign = 1) items of the order in shop stock
+func (e *EventHandler) applyOrder(event *kafka.Message, movementType string, sign float64) error {
+
+	var (
+		req    common.OrderCopyRequest
+		deltas = make([]*models.ShopStockDelta, 0)
+	)
+
+	if err := json.Unmarshal(event.Value, &req); err != nil {
+		return errors.Wrap(err, "error while unmarshal req")
+	}
+
+	if req.OrderId == "" || req.ShopId == "" {
+		return errors.New("order_id and shop_id are required")
+	}
+
+	for _, item := range req.Items {
+		deltas = append(deltas, &models.ShopStockDelta{
+			ProductId: item.ProductId,
+			ShopId:    req.ShopId,
+			Amount:    sign * float64(item.Value),
+			TotalSold: -sign * float64(item.Value),
+		})
+	}
+
+	return e.applyStockMovement(event, req.OrderId, movementType, deltas)
+}
@@ -7,6 +7,8 @@ import (
 	"genproto/catalog_service"
 	"genproto/common"
 
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
 	"github.com/Invan2/invan_catalog_service/pkg/telegram"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/pkg/errors"
@@ -41,6 +43,11 @@ func (e *EventHandler) CreateMultipleProducts(ctx context.Context, event *kafka.
 		}
 	}()
 
+	processed, err := e.markProcessed(tr, event)
+	if err != nil || !processed {
+		return err
+	}
+
 	err = tr.Product().InsertMany(req.Products)
 	if err != nil {
 		return err
@@ -51,7 +58,8 @@ func (e *EventHandler) CreateMultipleProducts(ctx context.Context, event *kafka.
 		return err
 	}
 
-	err = e.Push("v1.inventory_service.create_multiple_products_on_order_service", req)
+	// products of the event belong to one company
+	_, err = tr.Outbox().Create("v1.inventory_service.create_multiple_products_on_order_service", req.Products[0].GetRequest().GetCompanyId(), &req)
 	if err != nil {
 		return err
 	}
@@ -79,6 +87,11 @@ func (e *EventHandler) UpdateShopPrice(ctx context.Context, event *kafka.Message
 		}
 	}()
 
+	processed, err := e.markProcessed(tr, event)
+	if err != nil || !processed {
+		return err
+	}
+
 	err = tr.Product().UpsertShopRetailPrice(&req)
 	if err != nil {
 		return err
@@ -91,3 +104,24 @@ func (e *EventHandler) UpdateShopPrice(ctx context.Context, event *kafka.Message
 
 	return nil
 }
+
+// IndexProducts rebuilds elastic documents of the products from postgres, it is safe to handle the event again
+func (e *EventHandler) IndexProducts(ctx context.Context, event *kafka.Message) error {
+
+	var req common.RequestIDs
+
+	if err := json.Unmarshal(event.Value, &req); err != nil {
+		return errors.Wrap(err, "error while unmarshal req")
+	}
+
+	if len(req.Ids) == 0 {
+		return nil
+	}
+
+	documents, err := e.strgPG.Product().GetForIndex(&models.ProductIndexFilter{CompanyId: req.GetRequest().GetCompanyId(), ProductIds: req.Ids}, "", len(req.Ids))
+	if err != nil {
+		return err
+	}
+
+	return e.strgES.ProductIndex().BulkIndex(config.ElasticProductIndex, documents)
+}
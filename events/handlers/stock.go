@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/pkg/errors"
+)
+
+// applyStockMovement applies deltas of the document to shop stock only once per document and movement type
+func (e *EventHandler) applyStockMovement(event *kafka.Message, documentId, movementType string, deltas []*models.ShopStockDelta) error {
+
+	tr, err := e.strgPG.WithTransaction()
+	if err != nil {
+		return errors.Wrap(err, "error while run transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tr.Rollback()
+		} else {
+			_ = tr.Commit()
+		}
+	}()
+
+	processed, err := e.markProcessed(tr, event)
+	if err != nil || !processed {
+		return err
+	}
+
+	registered, err := tr.StockMovement().Register(documentId, movementType)
+	if err != nil {
+		return err
+	}
+
+	if !registered {
+		e.log.Info("stock movement already applied", logger.String("document_id", documentId), logger.String("type", movementType))
+		return nil
+	}
+
+	err = tr.Product().ApplyStockDeltas(deltas)
+	if err != nil {
+		return err
+	}
+
+	err = e.strgES.Product().ApplyStockDeltas(deltas)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
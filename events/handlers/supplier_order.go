@@ -13,6 +13,11 @@ func (e *EventHandler) UpsertMeasurementValue(ctx context.Context, event *kafka.
 
 	var request catalog_service.UpsertShopMeasurmentValueRequest
 
+	if err := json.Unmarshal(event.Value, &request); err != nil {
+		return err
+	}
+	e.log.Info("UpsertMeasurementValue", logger.Any("event", &request))
+
 	tr, err := e.strgPG.WithTransaction()
 	if err != nil {
 		return err
@@ -26,16 +31,18 @@ func (e *EventHandler) UpsertMeasurementValue(ctx context.Context, event *kafka.
 		}
 	}()
 
-	if err := json.Unmarshal(event.Value, &request); err != nil {
+	processed, err := e.markProcessed(tr, event)
+	if err != nil || !processed {
 		return err
 	}
-	e.log.Info("UpsertMeasurementValue", logger.Any("event", request))
 
-	if err := e.strgPG.Product().UpsertShopMeasurmentValue(&request); err != nil {
+	err = tr.Product().UpsertShopMeasurmentValue(&request)
+	if err != nil {
 		return err
 	}
 
-	if err := e.strgES.Product().UpsertShopMeasurmentValue(&request); err != nil {
+	err = e.strgES.Product().UpsertShopMeasurmentValue(&request)
+	if err != nil {
 		return err
 	}
 
@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/Invan2/invan_catalog_service/config"
 	"github.com/Invan2/invan_catalog_service/events/handlers"
 	"github.com/Invan2/invan_catalog_service/events/topics"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/helper"
 	"github.com/Invan2/invan_catalog_service/pkg/logger"
 	"github.com/Invan2/invan_catalog_service/storage"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
@@ -17,53 +20,62 @@ import (
 
 type pubSubServer struct {
 	log           logger.Logger
+	cfg           config.Config
 	producer      *kafka.Producer
 	consumerGroup *kafka.Consumer
 	eventHandlers map[string]EventHandler
 	strgPG        storage.StoragePg
 	strgES        storage.StorageES
 	ctx           context.Context
+	cancel        context.CancelFunc
+	done          chan struct{}
+	outboxKick    chan struct{}
+	workers       []chan *job
+	inFlight      chan struct{}
+	trackers      map[string]*offsetTracker
+	trackersMu    sync.Mutex
 	wg            *sync.WaitGroup
 	minioClient   *minio.Client
 }
 
 type PubSubServer interface {
 	Run(ctx context.Context) error
-	Push(topic string, value interface{}) error
+	Push(topic string, key string, value interface{}) error
 	AddConsumer(topic string, handler EventHandler)
+	Replay(letter *models.DeadLetter) error
+	WaitOutbox(ctx context.Context, id int64) error
 
 	Shutdown() error
 }
 
 type EventHandler func(ctx context.Context, event *kafka.Message) error
 
-func NewPubSubServer(log logger.Logger, producer *kafka.Producer, consumer *kafka.Consumer, strgPG storage.StoragePg, strgEs storage.StorageES) (PubSubServer, error) {
+func NewPubSubServer(log logger.Logger, cfg config.Config, producer *kafka.Producer, consumer *kafka.Consumer, strgPG storage.StoragePg, strgEs storage.StorageES) (PubSubServer, error) {
 	return &pubSubServer{
 		log:           log,
+		cfg:           cfg,
 		producer:      producer,
 		consumerGroup: consumer,
 		eventHandlers: make(map[string]EventHandler),
+		trackers:      make(map[string]*offsetTracker),
+		done:          make(chan struct{}),
+		outboxKick:    make(chan struct{}, 1),
 		strgPG:        strgPG,
 		strgES:        strgEs,
 		wg:            &sync.WaitGroup{},
 	}, nil
 }
 
-func (p *pubSubServer) Push(topic string, value interface{}) error {
+func (p *pubSubServer) Push(topic string, key string, value interface{}) error {
 
 	data, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
 
-	deliveryChan := make(chan kafka.Event, 10000)
-	err = p.producer.Produce(&kafka.Message{
-		TopicPartition: kafka.TopicPartition{
-			Topic:     &topic,
-			Partition: kafka.PartitionAny,
-		},
-		Value: data,
-	}, deliveryChan)
+	err = helper.ProduceSync(p.producer, helper.NewKafkaMessage(topic, key, data, helper.EventHeaders{
+		Source: p.eventSource(),
+	}), p.cfg.KafkaDeliveryTimeout)
 	if err != nil {
 		return err
 	}
@@ -72,6 +84,14 @@ func (p *pubSubServer) Push(topic string, value interface{}) error {
 
 }
 
+func (p *pubSubServer) eventSource() string {
+	if p.cfg.ServiceName != "" {
+		return p.cfg.ServiceName
+	}
+
+	return config.EventSource
+}
+
 func (p *pubSubServer) AddConsumer(topic string, handler EventHandler) {
 	_, ok := p.eventHandlers[topic]
 	if ok {
@@ -82,7 +102,7 @@ func (p *pubSubServer) AddConsumer(topic string, handler EventHandler) {
 }
 
 func (p *pubSubServer) registerConsumers() {
-	handlerV1 := handlers.NewHandler(p.log, p.strgPG, p.strgES, p.producer)
+	handlerV1 := handlers.NewHandler(p.log, p.strgPG, p.strgES, p.producer, p.eventSource(), p.cfg.KafkaDeliveryTimeout)
 
 	p.AddConsumer(topics.CompanyCreateTopic, handlerV1.CreateCompany)
 
@@ -98,13 +118,26 @@ func (p *pubSubServer) registerConsumers() {
 
 	// product pride
 	p.AddConsumer(topics.UpdateShopPriceTopic, handlerV1.UpdateShopPrice)
+	p.AddConsumer(topics.IndexProductsTopic, handlerV1.IndexProducts)
 	p.AddConsumer(topics.SupplierCreateTopic, handlerV1.UpsertSupplier)
 	p.AddConsumer(topics.SupplierDeleteTopic, handlerV1.DeleteSupplier)
 
+	// order
+	p.AddConsumer(topics.OrderFinishedTopic, handlerV1.OrderFinished)
+	p.AddConsumer(topics.OrderReturnedTopic, handlerV1.OrderReturned)
+
+	// inventory
+	p.AddConsumer(topics.TransferSentTopic, handlerV1.TransferSent)
+	p.AddConsumer(topics.TransferArrivedTopic, handlerV1.TransferArrived)
+	p.AddConsumer(topics.WriteOffFinishedTopic, handlerV1.WriteOffFinished)
+
 }
 
 func (p *pubSubServer) Run(ctx context.Context) error {
-	p.ctx = ctx
+	defer close(p.done)
+
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	ctx = p.ctx
 
 	p.registerConsumers()
 
@@ -124,10 +157,16 @@ func (p *pubSubServer) Run(ctx context.Context) error {
 		return errors.New("no topics")
 	}
 
-	if err := p.consumerGroup.SubscribeTopics(topics, nil); err != nil {
+	if err := p.consumerGroup.SubscribeTopics(topics, p.rebalance); err != nil {
 		return err
 	}
 
+	p.startWorkers(ctx)
+	defer p.stopWorkers()
+
+	p.wg.Add(1)
+	go p.runOutboxRelay(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -138,23 +177,7 @@ func (p *pubSubServer) Run(ctx context.Context) error {
 
 			switch e := e.(type) {
 			case *kafka.Message:
-				handler, ok := p.eventHandlers[*e.TopicPartition.Topic]
-				if !ok {
-					p.log.Error("handler not found", logger.String("topic", *e.TopicPartition.Topic))
-					continue
-				}
-
-				p.wg.Add(1)
-
-				go func() {
-
-					defer p.wg.Done()
-
-					if err := handler(ctx, e); err != nil {
-						p.log.Error("error while handling event", logger.Error(err))
-						return
-					}
-				}()
+				p.dispatch(ctx, e)
 			case kafka.Error:
 				p.log.Error("error while consuming", logger.Error(e))
 			default:
@@ -166,17 +189,22 @@ func (p *pubSubServer) Run(ctx context.Context) error {
 }
 
 func (p *pubSubServer) Shutdown() error {
-	select {
-	case <-p.ctx.Done():
-		p.log.Warn("terminating: context cancelled")
-	default:
+	if p.cancel != nil {
+		p.cancel()
+		<-p.done
 	}
 
+	p.wg.Wait()
+
 	if err := p.consumerGroup.Close(); err != nil {
 		return err
 	}
 
-	p.wg.Wait()
+	if left := p.producer.Flush(int(p.cfg.KafkaDeliveryTimeout.Milliseconds())); left > 0 {
+		p.log.Warn("messages are not delivered on shutdown", logger.Int("count", left))
+	}
+
+	p.producer.Close()
 
 	// fmt.Println("pub sub server stopped")
 
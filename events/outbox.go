@@ -0,0 +1,162 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Invan2/invan_catalog_service/pkg/helper"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/pkg/errors"
+)
+
+// runOutboxRelay publishes messages written to the outbox table in the order they were written
+func (p *pubSubServer) runOutboxRelay(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.OutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.outboxKick:
+		}
+
+		for {
+			sent, err := p.relayOutbox()
+			if err != nil {
+				p.log.Error("error while relay outbox", logger.Error(err))
+				break
+			}
+
+			if sent < p.cfg.OutboxBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
+
+// relayOutbox publishes a batch of messages and marks sent the messages acknowledged by the broker.
+// Messages after the first failed one stay not sent to keep the order
+func (p *pubSubServer) relayOutbox() (sent int, err error) {
+
+	tr, err := p.strgPG.WithTransaction()
+	if err != nil {
+		return 0, errors.Wrap(err, "error while run transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tr.Rollback()
+		} else {
+			_ = tr.Commit()
+		}
+	}()
+
+	locked, err := tr.Outbox().Lock()
+	if err != nil || !locked {
+		return 0, err
+	}
+
+	messages, err := tr.Outbox().GetNotSent(p.cfg.OutboxBatchSize)
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+
+	var (
+		deliveryChan = make(chan kafka.Event, len(messages))
+		delivered    = make(map[int64]error, len(messages))
+		produced     int
+	)
+
+	for _, message := range messages {
+		kafkaMessage := helper.NewKafkaMessage(message.Topic, message.Key, message.Payload, helper.EventHeaders{
+			Id:            message.EventId,
+			Source:        p.eventSource(),
+			Time:          message.CreatedAt,
+			SchemaVersion: message.SchemaVersion,
+		})
+		kafkaMessage.Opaque = message.Id
+
+		if produceErr := p.producer.Produce(kafkaMessage, deliveryChan); produceErr != nil {
+			p.log.Error("error while produce outbox message", logger.Error(produceErr))
+			break
+		}
+
+		produced++
+	}
+
+	timeout := time.After(p.cfg.KafkaDeliveryTimeout)
+
+wait:
+	for len(delivered) < produced {
+		select {
+		case e := <-deliveryChan:
+			m, ok := e.(*kafka.Message)
+			if !ok {
+				continue
+			}
+
+			id, _ := m.Opaque.(int64)
+			delivered[id] = m.TopicPartition.Error
+		case <-timeout:
+			p.log.Error("outbox messages delivery timed out", logger.Int("produced", produced), logger.Int("delivered", len(delivered)))
+			break wait
+		}
+	}
+
+	ids := make([]int64, 0, len(messages))
+
+	for _, message := range messages {
+		deliveryErr, ok := delivered[message.Id]
+		if !ok {
+			break
+		}
+
+		if deliveryErr != nil {
+			p.log.Error("error while deliver outbox message", logger.String("topic", message.Topic), logger.Error(deliveryErr))
+			break
+		}
+
+		ids = append(ids, message.Id)
+	}
+
+	return len(ids), tr.Outbox().MarkSent(ids)
+}
+
+// WaitOutbox waits until the outbox message is acknowledged by the broker
+func (p *pubSubServer) WaitOutbox(ctx context.Context, id int64) error {
+
+	timeout := time.After(p.cfg.KafkaDeliveryTimeout)
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case p.outboxKick <- struct{}{}:
+		default:
+		}
+
+		sent, err := p.strgPG.Outbox().IsSent(id)
+		if err != nil {
+			return err
+		}
+
+		if sent {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return fmt.Errorf("event is not delivered in %s", p.cfg.KafkaDeliveryTimeout)
+		case <-ticker.C:
+		}
+	}
+}
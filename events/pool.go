@@ -0,0 +1,231 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+type job struct {
+	message *kafka.Message
+	tracker *offsetTracker
+}
+
+// offsetTracker keeps offsets of the partition which are received but not handled yet,
+// so that only the offset before the first unhandled message is committed
+type offsetTracker struct {
+	mu      sync.Mutex
+	pending []kafka.Offset
+	handled map[kafka.Offset]bool
+	revoked bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		pending: make([]kafka.Offset, 0),
+		handled: make(map[kafka.Offset]bool),
+	}
+}
+
+func (t *offsetTracker) add(offset kafka.Offset) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = append(t.pending, offset)
+}
+
+// done marks the offset as handled and returns offset to commit
+func (t *offsetTracker) done(offset kafka.Offset) (kafka.Offset, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.handled[offset] = true
+
+	var (
+		commit kafka.Offset
+		ok     bool
+	)
+
+	for len(t.pending) > 0 && t.handled[t.pending[0]] {
+		commit = t.pending[0] + 1
+		ok = true
+
+		delete(t.handled, t.pending[0])
+		t.pending = t.pending[1:]
+	}
+
+	return commit, ok && !t.revoked
+}
+
+func (t *offsetTracker) revoke() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.revoked = true
+}
+
+func (t *offsetTracker) isRevoked() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.revoked
+}
+
+func partitionKey(tp kafka.TopicPartition) string {
+	return fmt.Sprintf("%s/%d", *tp.Topic, tp.Partition)
+}
+
+// orderingKey returns key of the message or its partition if the message has no key
+func orderingKey(message *kafka.Message) string {
+	if len(message.Key) > 0 {
+		return *message.TopicPartition.Topic + "/" + string(message.Key)
+	}
+
+	return partitionKey(message.TopicPartition)
+}
+
+func (p *pubSubServer) startWorkers(ctx context.Context) {
+	workers := p.cfg.KafkaWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	maxInFlight := p.cfg.KafkaMaxInFlight
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+
+	p.inFlight = make(chan struct{}, maxInFlight)
+	p.workers = make([]chan *job, workers)
+
+	for i := range p.workers {
+		p.workers[i] = make(chan *job, p.cfg.KafkaWorkerQueueSize)
+
+		p.wg.Add(1)
+		go p.runWorker(ctx, p.workers[i])
+	}
+}
+
+func (p *pubSubServer) stopWorkers() {
+	for _, worker := range p.workers {
+		close(worker)
+	}
+}
+
+// dispatch blocks while there are KafkaMaxInFlight unhandled events
+func (p *pubSubServer) dispatch(ctx context.Context, message *kafka.Message) {
+
+	select {
+	case p.inFlight <- struct{}{}:
+	default:
+		p.log.Warn("too many events in flight, waiting", logger.Int("max", cap(p.inFlight)))
+
+		select {
+		case p.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	tracker := p.tracker(message.TopicPartition)
+	tracker.add(message.TopicPartition.Offset)
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(orderingKey(message)))
+
+	select {
+	case p.workers[h.Sum32()%uint32(len(p.workers))] <- &job{message: message, tracker: tracker}:
+	case <-ctx.Done():
+		<-p.inFlight
+	}
+}
+
+func (p *pubSubServer) runWorker(ctx context.Context, jobs chan *job) {
+	defer p.wg.Done()
+
+	for j := range jobs {
+		p.runJob(ctx, j)
+		<-p.inFlight
+	}
+}
+
+func (p *pubSubServer) runJob(ctx context.Context, j *job) {
+	var message = j.message
+
+	// partition is assigned to another consumer, message will be redelivered there
+	if ctx.Err() != nil || j.tracker.isRevoked() {
+		return
+	}
+
+	handler, ok := p.eventHandlers[*message.TopicPartition.Topic]
+	if !ok {
+		p.log.Error("handler not found", logger.String("topic", *message.TopicPartition.Topic))
+		p.commit(j)
+		return
+	}
+
+	// the offset is not committed until the event is handled or stored as dead letter,
+	// it is redelivered after restart
+	if err := p.handle(ctx, handler, message); err != nil {
+		p.log.Warn("event is not handled, offset is not committed",
+			logger.String("topic", *message.TopicPartition.Topic),
+			logger.String("offset", message.TopicPartition.Offset.String()),
+			logger.Error(err),
+		)
+		return
+	}
+
+	p.commit(j)
+}
+
+func (p *pubSubServer) commit(j *job) {
+	offset, ok := j.tracker.done(j.message.TopicPartition.Offset)
+	if !ok {
+		return
+	}
+
+	tp := j.message.TopicPartition
+	tp.Offset = offset
+
+	if _, err := p.consumerGroup.CommitOffsets([]kafka.TopicPartition{tp}); err != nil {
+		p.log.Error("error while commit offset", logger.String("partition", partitionKey(tp)), logger.Error(err))
+	}
+}
+
+func (p *pubSubServer) tracker(tp kafka.TopicPartition) *offsetTracker {
+	p.trackersMu.Lock()
+	defer p.trackersMu.Unlock()
+
+	key := partitionKey(tp)
+
+	tracker, ok := p.trackers[key]
+	if !ok {
+		tracker = newOffsetTracker()
+		p.trackers[key] = tracker
+	}
+
+	return tracker
+}
+
+func (p *pubSubServer) rebalance(c *kafka.Consumer, event kafka.Event) error {
+	switch e := event.(type) {
+	case kafka.RevokedPartitions:
+		p.trackersMu.Lock()
+		defer p.trackersMu.Unlock()
+
+		for _, tp := range e.Partitions {
+			key := partitionKey(tp)
+
+			if tracker, ok := p.trackers[key]; ok {
+				tracker.revoke()
+				delete(p.trackers, key)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+func TestOffsetTracker(t *testing.T) {
+
+	type commit struct {
+		offset kafka.Offset
+		ok     bool
+	}
+
+	tests := []struct {
+		name    string
+		pending []kafka.Offset
+		done    []kafka.Offset
+		revoke  bool
+		want    []commit
+	}{
+		{
+			name:    "in order",
+			pending: []kafka.Offset{1, 2, 3},
+			done:    []kafka.Offset{1, 2, 3},
+			want:    []commit{{2, true}, {3, true}, {4, true}},
+		},
+		{
+			name:    "out of order waits for the first pending",
+			pending: []kafka.Offset{1, 2, 3},
+			done:    []kafka.Offset{3, 2, 1},
+			want:    []commit{{0, false}, {0, false}, {4, true}},
+		},
+		{
+			name:    "gap in the middle",
+			pending: []kafka.Offset{10, 11, 12, 13},
+			done:    []kafka.Offset{10, 12, 13, 11},
+			want:    []commit{{11, true}, {0, false}, {0, false}, {14, true}},
+		},
+		{
+			name:    "revoked partition is not committed",
+			pending: []kafka.Offset{1, 2},
+			done:    []kafka.Offset{1, 2},
+			revoke:  true,
+			want:    []commit{{0, false}, {0, false}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newOffsetTracker()
+
+			for _, offset := range tt.pending {
+				tracker.add(offset)
+			}
+
+			if tt.revoke {
+				tracker.revoke()
+			}
+
+			for i, offset := range tt.done {
+				got, ok := tracker.done(offset)
+				if ok != tt.want[i].ok || (ok && got != tt.want[i].offset) {
+					t.Errorf("done(%d) = %d, %v, want %d, %v", offset, got, ok, tt.want[i].offset, tt.want[i].ok)
+				}
+			}
+
+			if len(tracker.pending) != 0 || len(tracker.handled) != 0 {
+				t.Errorf("tracker is not empty: pending %v, handled %v", tracker.pending, tracker.handled)
+			}
+		})
+	}
+}
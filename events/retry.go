@@ -0,0 +1,222 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/helper"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/pkg/errors"
+)
+
+const (
+	dlqSuffix = ".dlq"
+
+	dlqHeaderTopic     = "dlq_original_topic"
+	dlqHeaderPartition = "dlq_original_partition"
+	dlqHeaderOffset    = "dlq_original_offset"
+	dlqHeaderError     = "dlq_error"
+	dlqHeaderAttempts  = "dlq_attempts"
+	dlqHeaderFailedAt  = "dlq_failed_at"
+)
+
+// handle runs the handler with exponential backoff and sends the message to the dead letter topic
+// when all attempts of the topic are failed. It returns an error only if the context is done
+// before the message was handled or stored as dead letter
+func (p *pubSubServer) handle(ctx context.Context, handler EventHandler, message *kafka.Message) error {
+
+	var (
+		topic       = *message.TopicPartition.Topic
+		headers     = helper.KafkaEventHeaders(message)
+		maxAttempts = p.maxAttempts(topic)
+		backoff     = p.cfg.KafkaRetryInitialBackoff
+		err         error
+		attempt     int
+	)
+
+	if headers.TraceId == "" {
+		headers.TraceId = headers.Id
+	}
+
+	ctx = helper.ContextWithTraceId(ctx, headers.TraceId)
+
+	for attempt = 1; attempt <= maxAttempts; attempt++ {
+		if err = handler(ctx, message); err == nil {
+			return nil
+		}
+
+		p.log.Error("error while handling event",
+			logger.String("topic", topic),
+			logger.String("event_id", headers.Id),
+			logger.String("trace_id", headers.TraceId),
+			logger.Int("attempt", attempt),
+			logger.Error(err),
+		)
+
+		if attempt == maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > p.cfg.KafkaRetryMaxBackoff {
+			backoff = p.cfg.KafkaRetryMaxBackoff
+		}
+	}
+
+	if err := p.sendToDeadLetter(ctx, message, err, maxAttempts); err != nil {
+		p.log.Error("error while sending event to dead letter", logger.String("topic", topic), logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (p *pubSubServer) maxAttempts(topic string) int {
+	attempts, ok := p.cfg.KafkaTopicRetryAttempts[topic]
+	if !ok {
+		attempts = p.cfg.KafkaRetryMaxAttempts
+	}
+
+	if attempts < 1 {
+		return 1
+	}
+
+	return attempts
+}
+
+// sendToDeadLetter publishes the message to the dead letter topic and stores it in dead_letter table.
+// The message counts as stored when one of them succeeded, a letter which is only in the topic is
+// logged to be added to the table from there. While both of them fail the partition is stalled
+func (p *pubSubServer) sendToDeadLetter(ctx context.Context, message *kafka.Message, handlerErr error, attempts int) error {
+
+	var (
+		topic    = *message.TopicPartition.Topic
+		dlqTopic = topic + dlqSuffix
+		headers  = make(map[string]string)
+		errText  string
+	)
+
+	if handlerErr != nil {
+		errText = handlerErr.Error()
+	}
+
+	for _, header := range message.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+
+	dlqHeaders := append([]kafka.Header{}, message.Headers...)
+	dlqHeaders = append(dlqHeaders,
+		kafka.Header{Key: dlqHeaderTopic, Value: []byte(topic)},
+		kafka.Header{Key: dlqHeaderPartition, Value: []byte(strconv.Itoa(int(message.TopicPartition.Partition)))},
+		kafka.Header{Key: dlqHeaderOffset, Value: []byte(message.TopicPartition.Offset.String())},
+		kafka.Header{Key: dlqHeaderError, Value: []byte(errText)},
+		kafka.Header{Key: dlqHeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: dlqHeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+
+	produce := func() error {
+		return helper.ProduceSync(p.producer, &kafka.Message{
+			TopicPartition: kafka.TopicPartition{
+				Topic:     &dlqTopic,
+				Partition: kafka.PartitionAny,
+			},
+			Key:     message.Key,
+			Value:   message.Value,
+			Headers: dlqHeaders,
+		}, p.cfg.KafkaDeliveryTimeout)
+	}
+
+	letter := models.DeadLetter{
+		Topic:     topic,
+		Partition: message.TopicPartition.Partition,
+		Offset:    int64(message.TopicPartition.Offset),
+		Key:       string(message.Key),
+		Value:     string(message.Value),
+		Headers:   headers,
+		Error:     errText,
+		Attempts:  attempts,
+	}
+
+	var (
+		produceErr = produce()
+		backoff    = p.cfg.KafkaRetryInitialBackoff
+	)
+
+	for attempt := 1; ; attempt++ {
+		err := p.strgPG.DeadLetter().Create(&letter)
+		if err == nil {
+			return nil
+		}
+
+		if produceErr == nil && attempt >= p.cfg.KafkaDeadLetterAttempts {
+			p.log.Error("error while save dead letter, event is stored only in dead letter topic",
+				logger.String("topic", dlqTopic),
+				logger.Int("partition", int(message.TopicPartition.Partition)),
+				logger.String("offset", message.TopicPartition.Offset.String()),
+				logger.Error(err),
+			)
+			return nil
+		}
+
+		p.log.Error("error while store dead letter, retrying",
+			logger.String("topic", topic),
+			logger.Int("attempt", attempt),
+			logger.Any("produce_error", produceErr),
+			logger.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > p.cfg.KafkaRetryMaxBackoff {
+			backoff = p.cfg.KafkaRetryMaxBackoff
+		}
+
+		if produceErr != nil {
+			produceErr = produce()
+		}
+	}
+}
+
+// Replay publishes the dead letter to its original topic
+func (p *pubSubServer) Replay(letter *models.DeadLetter) error {
+
+	var headers = make([]kafka.Header, 0, len(letter.Headers))
+
+	for key, value := range letter.Headers {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+
+	var key []byte
+	if letter.Key != "" {
+		key = []byte(letter.Key)
+	}
+
+	err := helper.ProduceSync(p.producer, &kafka.Message{
+		TopicPartition: kafka.TopicPartition{
+			Topic:     &letter.Topic,
+			Partition: kafka.PartitionAny,
+		},
+		Key:     key,
+		Value:   []byte(letter.Value),
+		Headers: headers,
+	}, p.cfg.KafkaDeliveryTimeout)
+	if err != nil {
+		return errors.Wrap(err, "error while replay dead letter")
+	}
+
+	return nil
+}
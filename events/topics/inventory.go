@@ -0,0 +1,7 @@
+package topics
+
+var (
+	TransferSentTopic     = "v1.inventory_service.transfer.sent.success"
+	TransferArrivedTopic  = "v1.inventory_service.transfer.arrived.success"
+	WriteOffFinishedTopic = "v1.inventory_service.write_off.finished.success"
+)
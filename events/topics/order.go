@@ -0,0 +1,6 @@
+package topics
+
+var (
+	OrderFinishedTopic = "v1.order_service.order.finished.success"
+	OrderReturnedTopic = "v1.order_service.order.returned.success"
+)
@@ -2,4 +2,6 @@ package topics
 
 var (
 	UpdateShopPriceTopic = "v1.catalog_service.product.shop_price.updated"
+	// IndexProductsTopic rebuilds elastic documents of the products from postgres
+	IndexProductsTopic = "v1.catalog_service.products.index"
 )
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS "stock_movement" (
+    "document_id" UUID NOT NULL,
+    "type" VARCHAR(50) NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("document_id", "type")
+);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS "processed_event" (
+    "id" VARCHAR(255) PRIMARY KEY,
+    "topic" VARCHAR(255) NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
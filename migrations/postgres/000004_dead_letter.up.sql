@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "dead_letter" (
+    "id" UUID PRIMARY KEY,
+    "topic" VARCHAR(255) NOT NULL,
+    "partition" INTEGER NOT NULL,
+    "offset" BIGINT NOT NULL,
+    "key" TEXT NOT NULL DEFAULT '',
+    "value" TEXT NOT NULL DEFAULT '',
+    "headers" JSONB NOT NULL DEFAULT '{}',
+    "error" TEXT NOT NULL DEFAULT '',
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "replayed_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "dead_letter_topic_created_at_idx" ON "dead_letter" ("topic", "created_at");
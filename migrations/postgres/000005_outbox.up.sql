@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "outbox" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "topic" VARCHAR(255) NOT NULL,
+    "key" VARCHAR(255) NOT NULL DEFAULT '',
+    "payload" JSONB NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "sent_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "outbox_not_sent_idx" ON "outbox" ("id") WHERE "sent_at" IS NULL;
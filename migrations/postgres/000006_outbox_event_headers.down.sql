@@ -0,0 +1,2 @@
+ALTER TABLE "outbox" DROP COLUMN IF EXISTS "schema_version";
+ALTER TABLE "outbox" DROP COLUMN IF EXISTS "event_id";
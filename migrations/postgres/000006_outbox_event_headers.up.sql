@@ -0,0 +1,2 @@
+ALTER TABLE "outbox" ADD COLUMN IF NOT EXISTS "event_id" UUID NOT NULL DEFAULT uuid_generate_v4();
+ALTER TABLE "outbox" ADD COLUMN IF NOT EXISTS "schema_version" VARCHAR(20) NOT NULL DEFAULT '1';
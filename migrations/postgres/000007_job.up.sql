@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "job" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "type" VARCHAR(64) NOT NULL,
+    "status" VARCHAR(20) NOT NULL DEFAULT 'pending',
+    "payload" JSONB NOT NULL DEFAULT '{}',
+    "progress" INTEGER NOT NULL DEFAULT 0,
+    "result_url" TEXT NOT NULL DEFAULT '',
+    "error" TEXT NOT NULL DEFAULT '',
+    "cancel_requested" BOOLEAN NOT NULL DEFAULT FALSE,
+    "created_by" UUID,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "started_at" TIMESTAMP,
+    "finished_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "job_company_id_created_at_idx" ON "job" ("company_id", "created_at");
+CREATE INDEX IF NOT EXISTS "job_not_finished_idx" ON "job" ("created_at") WHERE "status" IN ('pending', 'running');
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "import_mapping_profile" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "name" VARCHAR NOT NULL,
+    "mapping" JSONB NOT NULL DEFAULT '{}',
+    "encoding" VARCHAR(20) NOT NULL DEFAULT '',
+    "delimiter" VARCHAR(1) NOT NULL DEFAULT '',
+    "decimal_separator" VARCHAR(1) NOT NULL DEFAULT '',
+    "created_by" UUID,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "import_mapping_profile_company_id_name_idx" ON "import_mapping_profile" ("company_id", "name") WHERE "deleted_at" = 0;
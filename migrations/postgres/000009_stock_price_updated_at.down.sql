@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS set_shop_price_updated_at ON "shop_price";
+DROP TRIGGER IF EXISTS set_measurement_values_updated_at ON "measurement_values";
+DROP FUNCTION IF EXISTS set_updated_at();
+
+ALTER TABLE "shop_price" DROP COLUMN IF EXISTS "updated_at";
+ALTER TABLE "measurement_values" DROP COLUMN IF EXISTS "updated_at";
@@ -0,0 +1,26 @@
+ALTER TABLE "measurement_values" ADD COLUMN IF NOT EXISTS "updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE "shop_price" ADD COLUMN IF NOT EXISTS "updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS "measurement_values_updated_at_idx" ON "measurement_values"("updated_at");
+CREATE INDEX IF NOT EXISTS "shop_price_updated_at_idx" ON "shop_price"("updated_at");
+
+CREATE OR REPLACE FUNCTION set_updated_at()
+  RETURNS TRIGGER
+  LANGUAGE PLPGSQL
+  AS
+$$
+BEGIN
+    NEW."updated_at" = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$;
+
+CREATE OR REPLACE TRIGGER set_measurement_values_updated_at
+    BEFORE UPDATE ON "measurement_values"
+    FOR EACH ROW
+    EXECUTE PROCEDURE set_updated_at();
+
+CREATE OR REPLACE TRIGGER set_shop_price_updated_at
+    BEFORE UPDATE ON "shop_price"
+    FOR EACH ROW
+    EXECUTE PROCEDURE set_updated_at();
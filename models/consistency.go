@@ -0,0 +1,10 @@
+package models
+
+import "genproto/catalog_service"
+
+// ProductDocument is the product stored on elastic with the sequence number it was read at
+type ProductDocument struct {
+	Product     *catalog_service.ProductES
+	SeqNo       int64
+	PrimaryTerm int64
+}
@@ -0,0 +1,28 @@
+package models
+
+type DeadLetter struct {
+	Id         string            `json:"id"`
+	Topic      string            `json:"topic"`
+	Partition  int32             `json:"partition"`
+	Offset     int64             `json:"offset"`
+	Key        string            `json:"key"`
+	Value      string            `json:"value"`
+	Headers    map[string]string `json:"headers"`
+	Error      string            `json:"error"`
+	Attempts   int               `json:"attempts"`
+	CreatedAt  string            `json:"created_at"`
+	ReplayedAt string            `json:"replayed_at"`
+}
+
+type GetDeadLettersRequest struct {
+	Limit int32  `json:"limit"`
+	Page  int32  `json:"page"`
+	Topic string `json:"topic"`
+	// NotReplayed returns only messages which were not replayed yet
+	NotReplayed bool `json:"not_replayed"`
+}
+
+type GetDeadLettersResponse struct {
+	Data  []*DeadLetter `json:"data"`
+	Total int32         `json:"total"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "genproto/common"
+
+type ImportProductsRequest struct {
+	Request *common.Request `json:"request"`
+	// FileName is name or url of the uploaded file in the file bucket
+	FileName string `json:"file_name"`
+	// ShopIds get quantity and prices of the rows, all shops of the company if empty
+	ShopIds []string `json:"shop_ids"`
+	// MatchBy finds existing products by "sku" or "barcode", VARIATION_ID is matched always
+	MatchBy string `json:"match_by"`
+	// Columns sets "overwrite" or "keep" by column for matched products, overwrite by default.
+	// Empty cells keep current values in both cases
+	Columns map[string]string `json:"columns"`
+	// DryRun counts the result without saving products
+	DryRun bool `json:"dry_run"`
+}
+
+type ImportRowError struct {
+	// Row is the row number in the file, header is row 1
+	Row    int    `json:"row"`
+	Column string `json:"column"`
+	Error  string `json:"error"`
+}
+
+type ImportProductsResponse struct {
+	Total   int `json:"total"`
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	// Skipped rows match products which would not change
+	Skipped  int               `json:"skipped"`
+	Rejected int               `json:"rejected"`
+	Errors   []*ImportRowError `json:"errors"`
+	// ErrorFileUrl is the uploaded file with bad cells marked, empty if there are no errors
+	ErrorFileUrl string `json:"error_file_url"`
+}
+
+type ImportMappingProfile struct {
+	Id        string `json:"id"`
+	CompanyId string `json:"company_id"`
+	Name      string `json:"name"`
+	// Mapping maps header of the file to the import column, e.g. "Наименование" to NAME
+	Mapping          map[string]string `json:"mapping"`
+	Encoding         string            `json:"encoding"`
+	Delimiter        string            `json:"delimiter"`
+	DecimalSeparator string            `json:"decimal_separator"`
+	CreatedBy        string            `json:"created_by"`
+	CreatedAt        string            `json:"created_at"`
+	UpdatedAt        string            `json:"updated_at"`
+}
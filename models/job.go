@@ -0,0 +1,31 @@
+package models
+
+type Job struct {
+	Id        string `json:"id"`
+	CompanyId string `json:"company_id"`
+	Type      string `json:"type"`
+	Status    string `json:"status"`
+	Payload   []byte `json:"-"`
+	// Progress is percentage from 0 to 100
+	Progress        int    `json:"progress"`
+	ResultUrl       string `json:"result_url"`
+	Error           string `json:"error"`
+	CancelRequested bool   `json:"cancel_requested"`
+	CreatedBy       string `json:"created_by"`
+	CreatedAt       string `json:"created_at"`
+	StartedAt       string `json:"started_at"`
+	FinishedAt      string `json:"finished_at"`
+}
+
+type GetJobsRequest struct {
+	CompanyId string `json:"company_id"`
+	Limit     int32  `json:"limit"`
+	Page      int32  `json:"page"`
+	Type      string `json:"type"`
+	Status    string `json:"status"`
+}
+
+type GetJobsResponse struct {
+	Data  []*Job `json:"data"`
+	Total int32  `json:"total"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type OutboxMessage struct {
+	Id            int64
+	EventId       string
+	Topic         string
+	Key           string
+	Payload       []byte
+	SchemaVersion string
+	CreatedAt     time.Time
+}
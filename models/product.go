@@ -94,13 +94,3 @@ type GetProductForLabel struct {
 	// MeasurementUnit   *ShortMeasurementUnit            //`json:"measurement_unit"`
 	// MeasurementValues map[string]*ShopMeasurementValue //`json:"value"`
 }
-
-type GetAllForExcelResponse struct {
-	Data  []map[string]interface{}
-	Total int64
-}
-
-type GetAllForCsvResponse struct {
-	Data  []map[string]interface{}
-	Total int64
-}
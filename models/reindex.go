@@ -0,0 +1,22 @@
+package models
+
+type ProductIndexFilter struct {
+	CompanyId string
+	// UpdatedSince is in config.DateTimeFormat
+	UpdatedSince string
+	ProductIds   []string
+}
+
+type ReindexProductsRequest struct {
+	// CompanyId rebuilds only documents of the company on the live index
+	CompanyId string `json:"company_id"`
+	// KeepOldIndex leaves the previous index in place after the alias swap
+	KeepOldIndex bool `json:"keep_old_index"`
+}
+
+type ReindexProductsResponse struct {
+	Index    string   `json:"index"`
+	Indexed  int      `json:"indexed"`
+	Deleted  int      `json:"deleted"`
+	OldIndex []string `json:"old_index"`
+}
@@ -0,0 +1,10 @@
+package models
+
+type CatalogReplayRequest struct {
+	CompanyId string `json:"company_id"`
+	// ShopId limits products and shop measurement values to the shop
+	ShopId string `json:"shop_id"`
+	// UpdatedSince is in config.DateTimeFormat, stock and price changes are counted as updates too
+	UpdatedSince string   `json:"updated_since"`
+	ProductIds   []string `json:"product_ids"`
+}
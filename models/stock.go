@@ -0,0 +1,11 @@
+package models
+
+// ShopStockDelta is added to the current measurement values of a product in a shop
+type ShopStockDelta struct {
+	ProductId            string  `json:"product_id"`
+	ShopId               string  `json:"shop_id"`
+	Amount               float64 `json:"amount"`
+	TotalSold            float64 `json:"total_sold"`
+	TotalTransfered      float64 `json:"total_transfered"`
+	TotalTransferArrived float64 `json:"total_transfer_arrived"`
+}
@@ -1,13 +1,16 @@
 package helper
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/Shopify/sarama"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
 )
 
 func MessageToEvent(message *sarama.ConsumerMessage) cloudevents.Event {
@@ -21,7 +24,7 @@ func MessageToEvent(message *sarama.ConsumerMessage) cloudevents.Event {
 		} else if x == "ce_type" {
 			event.SetType(string(header.Value))
 		} else if x == "ce_time" {
-			t, _ := time.Parse("2006-01-02T15:04:05.999999999Z", string(header.Value))
+			t, _ := time.Parse(eventTimeFormat, string(header.Value))
 			event.SetTime(t)
 		} else if x == "ce_traceid" {
 			event.SetExtension("traceid", string(header.Value))
@@ -48,3 +51,141 @@ func CreateEvent(value interface{}) cloudevents.Event {
 
 	return event
 }
+
+const (
+	EventSpecVersion     = "1.0"
+	EventSchemaVersion   = "1"
+	headerSchemaVersion  = "schema_version"
+	headerContentType    = "content-type"
+	eventTimeFormat      = "2006-01-02T15:04:05.999999999Z"
+	applicationJSONValue = "application/json"
+)
+
+// EventHeaders are CloudEvents attributes sent in kafka headers of every message
+type EventHeaders struct {
+	Id            string
+	Type          string
+	Source        string
+	Time          time.Time
+	TraceId       string
+	SchemaVersion string
+}
+
+// NewKafkaMessage builds message with CloudEvents headers, empty attributes are filled by defaults
+func NewKafkaMessage(topic string, key string, value []byte, headers EventHeaders) *kafka.Message {
+
+	if headers.Id == "" {
+		headers.Id = uuid.NewString()
+	}
+
+	if headers.Type == "" {
+		headers.Type = topic
+	}
+
+	if headers.Time.IsZero() {
+		headers.Time = time.Now()
+	}
+
+	if headers.TraceId == "" {
+		headers.TraceId = headers.Id
+	}
+
+	if headers.SchemaVersion == "" {
+		headers.SchemaVersion = EventSchemaVersion
+	}
+
+	message := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{
+			Topic:     &topic,
+			Partition: kafka.PartitionAny,
+		},
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "ce_specversion", Value: []byte(EventSpecVersion)},
+			{Key: "ce_id", Value: []byte(headers.Id)},
+			{Key: "ce_type", Value: []byte(headers.Type)},
+			{Key: "ce_source", Value: []byte(headers.Source)},
+			{Key: "ce_time", Value: []byte(headers.Time.UTC().Format(eventTimeFormat))},
+			{Key: "ce_traceid", Value: []byte(headers.TraceId)},
+			{Key: headerSchemaVersion, Value: []byte(headers.SchemaVersion)},
+			{Key: headerContentType, Value: []byte(applicationJSONValue)},
+		},
+	}
+
+	if key != "" {
+		message.Key = []byte(key)
+	}
+
+	return message
+}
+
+// KafkaEventHeaders reads CloudEvents headers of the message
+func KafkaEventHeaders(message *kafka.Message) EventHeaders {
+	var headers EventHeaders
+
+	for _, header := range message.Headers {
+		switch header.Key {
+		case "ce_id":
+			headers.Id = string(header.Value)
+		case "ce_type":
+			headers.Type = string(header.Value)
+		case "ce_source":
+			headers.Source = string(header.Value)
+		case "ce_time":
+			headers.Time, _ = time.Parse(eventTimeFormat, string(header.Value))
+		case "ce_traceid":
+			headers.TraceId = string(header.Value)
+		case headerSchemaVersion:
+			headers.SchemaVersion = string(header.Value)
+		}
+	}
+
+	return headers
+}
+
+type traceIdKey struct{}
+
+func ContextWithTraceId(ctx context.Context, traceId string) context.Context {
+	return context.WithValue(ctx, traceIdKey{}, traceId)
+}
+
+func TraceIdFromContext(ctx context.Context) string {
+	traceId, _ := ctx.Value(traceIdKey{}).(string)
+	return traceId
+}
+
+// KafkaMessageID returns ce_id header of the message or its topic/partition/offset if there is no header
+func KafkaMessageID(message *kafka.Message) string {
+	if id := KafkaEventHeaders(message).Id; id != "" {
+		return id
+	}
+
+	var topic string
+	if message.TopicPartition.Topic != nil {
+		topic = *message.TopicPartition.Topic
+	}
+
+	return fmt.Sprintf("%s/%d/%d", topic, message.TopicPartition.Partition, message.TopicPartition.Offset)
+}
+
+// ProduceSync produces the message and waits for the broker acknowledgement
+func ProduceSync(producer *kafka.Producer, message *kafka.Message, timeout time.Duration) error {
+
+	deliveryChan := make(chan kafka.Event, 1)
+
+	if err := producer.Produce(message, deliveryChan); err != nil {
+		return err
+	}
+
+	select {
+	case e := <-deliveryChan:
+		m, ok := e.(*kafka.Message)
+		if !ok {
+			return fmt.Errorf("unexpected delivery event: %v", e)
+		}
+
+		return m.TopicPartition.Error
+	case <-time.After(timeout):
+		return fmt.Errorf("delivery of message to %s timed out after %s", *message.TopicPartition.Topic, timeout)
+	}
+}
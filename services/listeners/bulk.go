@@ -14,6 +14,11 @@ import (
 	"github.com/pkg/errors"
 )
 
+// BulkGenerateProductLabelsJob returns id of the labels job, the file url is result of the job
+func (c *catalogService) BulkGenerateProductLabelsJob(ctx context.Context, req *catalog_service.GetProductLabelsRequest) (*common.ResponseID, error) {
+	return c.createJob(req.GetRequest(), config.JobProductLabels, req)
+}
+
 func (c *catalogService) BulkGenerateProductLabels(ctx context.Context, req *catalog_service.GetProductLabelsRequest) (*common.ResponseID, error) {
 
 	var (
@@ -26,34 +31,45 @@ func (c *catalogService) BulkGenerateProductLabels(ctx context.Context, req *cat
 		return nil, err
 	}
 
-	products, err := c.elastic.Product().GetForLabel(req)
-	if err != nil {
-		return nil, err
-	}
+	err = c.elastic.Product().ScanForLabel(ctx, req, func(products []*catalog_service.ProductES) error {
 
-	for _, product := range products.Data {
+		for _, product := range products {
 
-		r := map[string]interface{}{
-			"id":           product.Id,
-			"name":         product.Name,
-			"barcode":      product.Barcodes,
-			"mxik_code":    product.MxikCode,
-			"date":         time.Now().Format(config.DateFormat),
-			"retail_price": strconv.FormatFloat(float64(product.ShopPrices[req.ShopId].RetailPrice), 'E', -1, 64),
-		}
+			r := map[string]interface{}{
+				"id":           product.Id,
+				"name":         product.Name,
+				"barcode":      product.Barcodes,
+				"mxik_code":    product.MxikCode,
+				"date":         time.Now().Format(config.DateFormat),
+				"retail_price": strconv.FormatFloat(float64(product.ShopPrices[req.ShopId].RetailPrice), 'E', -1, 64),
+			}
+
+			if len(product.Barcodes) > 0 {
+				r["barcode"] = product.Barcodes[0]
+			}
 
-		if len(product.Barcodes) > 0 {
-			r["barcode"] = product.Barcodes[0]
+			productsMap = append(productsMap, r)
 		}
 
-		productsMap = append(productsMap, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	reportJobProgress(ctx, 30)
+
 	res.Id, err = c.pdf.MakeProductsLabel(productsMap, label)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	reportJobProgress(ctx, 80)
+
 	res.Id, err = c.uploadLabelToMinio(res.Id)
 	if err != nil {
 		return nil, err
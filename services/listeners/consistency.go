@@ -0,0 +1,266 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+	"genproto/catalog_service"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+const consistencyBatchSize = 500
+
+// CheckConsistency compares products of the company on postgres and elastic.
+// Repair writes a product only if its document was not changed since it was compared,
+// products found only on elastic are deleted if they are still missing on postgres
+func (c *catalogService) CheckConsistency(ctx context.Context, req *catalog_service.CheckConsistencyRequest) (*catalog_service.CheckConsistencyResponse, error) {
+
+	var (
+		afterId string
+		filter  models.ProductIndexFilter
+		seen    = make(map[string]bool)
+		missing = make([]string, 0)
+		res     = catalog_service.CheckConsistencyResponse{
+			Mismatches: make([]*catalog_service.ConsistencyMismatch, 0),
+		}
+	)
+
+	if req.Request == nil || req.Request.CompanyId == "" {
+		return nil, errors.New("company_id is required")
+	}
+
+	filter.CompanyId = req.Request.CompanyId
+	res.CompanyId = req.Request.CompanyId
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		products, err := c.strg.Product().GetForIndex(&filter, afterId, consistencyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := make([]string, 0, len(products))
+		for _, product := range products {
+			ids = append(ids, product.Id)
+			seen[product.Id] = true
+		}
+
+		documents, err := c.elastic.ProductIndex().GetByIds(config.ElasticProductIndex, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		repair := make([]string, 0)
+
+		for _, product := range products {
+			mismatches := compareProducts(product, documents[product.Id])
+			if len(mismatches) == 0 {
+				continue
+			}
+
+			res.Mismatches = append(res.Mismatches, mismatches...)
+			repair = append(repair, product.Id)
+		}
+
+		if req.Repair && len(repair) > 0 {
+			if err := c.repairProducts(&res, filter.CompanyId, repair); err != nil {
+				return nil, err
+			}
+		}
+
+		res.Checked += int32(len(products))
+
+		if len(products) < consistencyBatchSize {
+			break
+		}
+
+		afterId = products[len(products)-1].Id
+	}
+
+	indexed, err := c.elastic.ProductIndex().GetIds(config.ElasticProductIndex, filter.CompanyId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range indexed {
+		if !seen[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	stale, err := c.getStaleProductIds(filter.CompanyId, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range stale {
+		res.Mismatches = append(res.Mismatches, &catalog_service.ConsistencyMismatch{
+			ProductId: id,
+			Field:     "exists",
+			Postgres:  "false",
+			Elastic:   "true",
+		})
+	}
+
+	if req.Repair && len(stale) > 0 {
+		if err := c.elastic.ProductIndex().DeleteIds(config.ElasticProductIndex, stale); err != nil {
+			return nil, err
+		}
+
+		res.Repaired += int32(len(stale))
+	}
+
+	c.log.Info("products consistency checked",
+		logger.String("company_id", res.CompanyId),
+		logger.Int("checked", int(res.Checked)),
+		logger.Int("mismatches", len(res.Mismatches)),
+		logger.Int("repaired", int(res.Repaired)),
+		logger.Int("skipped", int(res.Skipped)),
+	)
+
+	return &res, nil
+}
+
+// getStaleProductIds returns ids which are still not found on postgres, products created after
+// their page was checked are indexed by their own create
+func (c *catalogService) getStaleProductIds(companyId string, ids []string) ([]string, error) {
+
+	var (
+		stale = make([]string, 0)
+		found = make(map[string]bool)
+	)
+
+	if len(ids) == 0 {
+		return stale, nil
+	}
+
+	products, err := c.strg.Product().GetForIndex(&models.ProductIndexFilter{CompanyId: companyId, ProductIds: ids}, "", len(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		found[product.Id] = true
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			stale = append(stale, id)
+		}
+	}
+
+	return stale, nil
+}
+
+// repairProducts reads documents before products, so a product changed after it was read
+// changes its document too and the conditional write of the older product is rejected
+func (c *catalogService) repairProducts(res *catalog_service.CheckConsistencyResponse, companyId string, ids []string) error {
+
+	documents, err := c.elastic.ProductIndex().GetByIds(config.ElasticProductIndex, ids)
+	if err != nil {
+		return err
+	}
+
+	products, err := c.strg.Product().GetForIndex(&models.ProductIndexFilter{CompanyId: companyId, ProductIds: ids}, "", len(ids))
+	if err != nil {
+		return err
+	}
+
+	conflicts, err := c.elastic.ProductIndex().BulkIndexIfUnchanged(config.ElasticProductIndex, products, documents)
+	if err != nil {
+		return err
+	}
+
+	res.Repaired += int32(len(products) - len(conflicts))
+	res.Skipped += int32(len(ids) - len(products) + len(conflicts))
+
+	return nil
+}
+
+func compareProducts(pg *catalog_service.ProductES, document *models.ProductDocument) []*catalog_service.ConsistencyMismatch {
+
+	var (
+		res = make([]*catalog_service.ConsistencyMismatch, 0)
+		es  *catalog_service.ProductES
+	)
+
+	mismatch := func(field, shopId, pgValue, esValue string) {
+		if pgValue != esValue {
+			res = append(res, &catalog_service.ConsistencyMismatch{
+				ProductId: pg.Id,
+				Field:     field,
+				ShopId:    shopId,
+				Postgres:  pgValue,
+				Elastic:   esValue,
+			})
+		}
+	}
+
+	if document == nil {
+		mismatch("exists", "", "true", "false")
+		return res
+	}
+
+	es = document.Product
+
+	mismatch("name", "", pg.Name, es.Name)
+	mismatch("sku", "", pg.Sku, es.Sku)
+	mismatch("barcodes", "", joinSorted(pg.Barcodes), joinSorted(es.Barcodes))
+
+	for shopId, pgPrice := range pg.ShopPrices {
+		esPrice := es.ShopPrices[shopId]
+		if esPrice == nil {
+			esPrice = &catalog_service.ShopPrice{}
+		}
+
+		mismatch("retail_price", shopId, formatAmount(pgPrice.RetailPrice), formatAmount(esPrice.RetailPrice))
+		mismatch("supply_price", shopId, formatAmount(pgPrice.SupplyPrice), formatAmount(esPrice.SupplyPrice))
+		mismatch("whole_sale_price", shopId, formatAmount(pgPrice.WholeSalePrice), formatAmount(esPrice.WholeSalePrice))
+		mismatch("min_price", shopId, formatAmount(pgPrice.MinPrice), formatAmount(esPrice.MinPrice))
+		mismatch("max_price", shopId, formatAmount(pgPrice.MaxPrice), formatAmount(esPrice.MaxPrice))
+	}
+
+	for shopId, pgValue := range pg.MeasurementValues {
+		esValue := es.MeasurementValues[shopId]
+		if esValue == nil {
+			esValue = &catalog_service.ShopMeasurementValue{}
+		}
+
+		mismatch("amount", shopId, formatAmount(pgValue.Amount), formatAmount(esValue.Amount))
+	}
+
+	for shopId := range es.ShopPrices {
+		if _, ok := pg.ShopPrices[shopId]; !ok {
+			mismatch("shop_price", shopId, "", "exists")
+		}
+	}
+
+	for shopId := range es.MeasurementValues {
+		if _, ok := pg.MeasurementValues[shopId]; !ok {
+			mismatch("measurement_value", shopId, "", "exists")
+		}
+	}
+
+	return res
+}
+
+func joinSorted(values []string) string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, ",")
+}
+
+// formatAmount rounds to the precision stored on elastic, float32 both sides
+func formatAmount(value float32) string {
+	return fmt.Sprintf("%.3f", math.Round(float64(value)*1000)/1000)
+}
@@ -0,0 +1,115 @@
+package listeners
+
+import (
+	"genproto/catalog_service"
+	"testing"
+
+	"github.com/Invan2/invan_catalog_service/models"
+)
+
+func TestCompareProducts(t *testing.T) {
+
+	product := func() *catalog_service.ProductES {
+		return &catalog_service.ProductES{
+			Id:       "p1",
+			Name:     "Milk",
+			Sku:      "100",
+			Barcodes: []string{"2", "1"},
+			ShopPrices: map[string]*catalog_service.ShopPrice{
+				"s1": {RetailPrice: 1000, SupplyPrice: 800},
+			},
+			MeasurementValues: map[string]*catalog_service.ShopMeasurementValue{
+				"s1": {Amount: 5},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		es     func(p *catalog_service.ProductES) *catalog_service.ProductES
+		want   []string
+		shopId string
+	}{
+		{
+			name: "equal",
+			es:   func(p *catalog_service.ProductES) *catalog_service.ProductES { return p },
+		},
+		{
+			name: "missing document",
+			es:   func(p *catalog_service.ProductES) *catalog_service.ProductES { return nil },
+			want: []string{"exists"},
+		},
+		{
+			name: "barcodes in other order",
+			es: func(p *catalog_service.ProductES) *catalog_service.ProductES {
+				p.Barcodes = []string{"1", "2"}
+				return p
+			},
+		},
+		{
+			name: "name and sku",
+			es: func(p *catalog_service.ProductES) *catalog_service.ProductES {
+				p.Name = "Kefir"
+				p.Sku = "101"
+				return p
+			},
+			want: []string{"name", "sku"},
+		},
+		{
+			name: "amount rounded to elastic precision",
+			es: func(p *catalog_service.ProductES) *catalog_service.ProductES {
+				p.MeasurementValues["s1"].Amount = 5.0001
+				return p
+			},
+		},
+		{
+			name: "amount",
+			es: func(p *catalog_service.ProductES) *catalog_service.ProductES {
+				p.MeasurementValues["s1"].Amount = 4
+				return p
+			},
+			want:   []string{"amount"},
+			shopId: "s1",
+		},
+		{
+			name: "missing shop price",
+			es: func(p *catalog_service.ProductES) *catalog_service.ProductES {
+				delete(p.ShopPrices, "s1")
+				return p
+			},
+			want:   []string{"retail_price", "supply_price"},
+			shopId: "s1",
+		},
+		{
+			name: "extra shop on elastic",
+			es: func(p *catalog_service.ProductES) *catalog_service.ProductES {
+				p.MeasurementValues["s2"] = &catalog_service.ShopMeasurementValue{}
+				return p
+			},
+			want:   []string{"measurement_value"},
+			shopId: "s2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document *models.ProductDocument
+
+			if es := tt.es(product()); es != nil {
+				document = &models.ProductDocument{Product: es}
+			}
+
+			got := compareProducts(product(), document)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("compareProducts() = %v, want fields %v", got, tt.want)
+			}
+
+			for i, mismatch := range got {
+				if mismatch.Field != tt.want[i] || mismatch.ShopId != tt.shopId || mismatch.ProductId != "p1" {
+					t.Errorf("mismatch %d = %v, want field %s of shop %q", i, mismatch, tt.want[i], tt.shopId)
+				}
+			}
+		})
+	}
+}
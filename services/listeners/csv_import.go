@@ -0,0 +1,272 @@
+package listeners
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"genproto/catalog_service"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	importEncodingUTF8   = "utf-8"
+	importEncodingCP1251 = "cp1251"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// importCsvDelimiters are the delimiters looked for in the header when the delimiter is not set
+var importCsvDelimiters = []rune{';', ',', '\t', '|'}
+
+func validateImportCsvOptions(encoding, delimiter, decimal string) error {
+
+	switch encoding {
+	case "", importEncodingUTF8, importEncodingCP1251:
+	default:
+		return fmt.Errorf("unknown encoding %q, must be %s or %s", encoding, importEncodingUTF8, importEncodingCP1251)
+	}
+
+	if utf8.RuneCountInString(delimiter) > 1 || strings.ContainsAny(delimiter, "\"\r\n") {
+		return fmt.Errorf("invalid delimiter %q", delimiter)
+	}
+
+	if decimal != "" && decimal != "." && decimal != "," {
+		return fmt.Errorf("invalid decimal separator %q, must be . or ,", decimal)
+	}
+
+	return nil
+}
+
+// decodeImportCsv converts the file to utf-8, the file without BOM is taken as cp1251 if it is not valid utf-8
+func decodeImportCsv(data []byte, encoding string) ([]byte, error) {
+
+	if bytes.HasPrefix(data, utf8BOM) {
+		return bytes.TrimPrefix(data, utf8BOM), nil
+	}
+
+	if encoding == "" {
+		encoding = importEncodingUTF8
+		if !utf8.Valid(data) {
+			encoding = importEncodingCP1251
+		}
+	}
+
+	if encoding != importEncodingCP1251 {
+		return data, nil
+	}
+
+	res, err := charmap.Windows1251.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while decoding cp1251")
+	}
+
+	return res, nil
+}
+
+// detectImportCsvDelimiter takes the most frequent delimiter of the header outside of quotes
+func detectImportCsvDelimiter(data []byte) rune {
+
+	var (
+		line   = data
+		counts = make(map[rune]int)
+		quoted bool
+		res    = ','
+	)
+
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+
+	for _, r := range string(line) {
+		if r == '"' {
+			quoted = !quoted
+			continue
+		}
+
+		if !quoted {
+			counts[r]++
+		}
+	}
+
+	for _, delimiter := range importCsvDelimiters {
+		if counts[delimiter] > counts[res] {
+			res = delimiter
+		}
+	}
+
+	return res
+}
+
+// detectImportDecimal takes the separator of the first number where it is not ambiguous, empty if there is none
+func detectImportDecimal(table *importTable) string {
+
+	for _, row := range table.rows {
+		for _, column := range append([]string{importQuantity}, importPriceColumns()...) {
+			value := strings.NewReplacer(" ", "", "\u00a0", "").Replace(row.value(column))
+			if !strings.ContainsAny(value, ".,") {
+				continue
+			}
+
+			if decimal, err := detectNumberDecimal(value); err == nil {
+				return decimal
+			}
+		}
+	}
+
+	return ""
+}
+
+// ImportProductsFromCsv imports csv file with the columns of the excel template or the headers of the mapping profile.
+// Encoding, delimiter and decimal separator are detected unless they are set by the request or the profile
+func (c *catalogService) ImportProductsFromCsv(ctx context.Context, req *catalog_service.ImportProductsCsvRequest) (*catalog_service.ImportProductsResponse, error) {
+
+	c.log.Info("ImportProductsFromCsv", logger.Any("request", req))
+
+	if req.Import == nil || req.Import.Request == nil || req.Import.FileName == "" {
+		return nil, errors.New("import request and file_name are required")
+	}
+
+	var (
+		mapping   = make(map[string]string)
+		encoding  = strings.ToLower(req.Encoding)
+		delimiter = req.Delimiter
+		decimal   = req.DecimalSeparator
+	)
+
+	if req.ProfileId != "" {
+		profile, err := c.strg.ImportMappingProfile().GetById(req.ProfileId, req.Import.Request.GetCompanyId())
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("import mapping profile not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for header, column := range profile.Mapping {
+			mapping[normalizeImportHeader(header)] = column
+		}
+
+		if encoding == "" {
+			encoding = profile.Encoding
+		}
+
+		if delimiter == "" {
+			delimiter = profile.Delimiter
+		}
+
+		if decimal == "" {
+			decimal = profile.DecimalSeparator
+		}
+	}
+
+	if err := validateImportCsvOptions(encoding, delimiter, decimal); err != nil {
+		return nil, err
+	}
+
+	data, err := c.readImportFile(ctx, req.Import.FileName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = decodeImportCsv(data, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	comma := detectImportCsvDelimiter(data)
+	if delimiter != "" {
+		comma, _ = utf8.DecodeRuneInString(delimiter)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "error while reading csv rows")
+	}
+
+	table, err := newImportTable(records, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	table.decimal = decimal
+	if table.decimal == "" {
+		table.decimal = detectImportDecimal(table)
+	}
+
+	res, err := c.importProducts(ctx, importRequestFromProto(req.Import), table)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Errors) > 0 && !req.Import.DryRun {
+		res.ErrorFileUrl, err = c.uploadCsvImportErrors(records, table, res.Errors, comma)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return importResponseToProto(res), nil
+}
+
+// uploadCsvImportErrors writes the imported rows with ERRORS column, the file is utf-8 with BOM to be opened by excel
+func (c *catalogService) uploadCsvImportErrors(records [][]string, table *importTable, rowErrors []*models.ImportRowError, comma rune) (string, error) {
+
+	var (
+		rowMessages = make(map[int][]string)
+		buf         bytes.Buffer
+	)
+
+	for _, rowError := range rowErrors {
+		rowMessages[rowError.Row] = append(rowMessages[rowError.Row], fmt.Sprintf("%s: %s", rowError.Column, rowError.Error))
+	}
+
+	buf.Write(utf8BOM)
+
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+
+	for i, record := range records {
+		row := make([]string, table.width, table.width+1)
+		copy(row, record)
+
+		if i == 0 {
+			row = append(row, "ERRORS")
+		} else {
+			row = append(row, strings.Join(rowMessages[i+1], "; "))
+		}
+
+		if err := w.Write(row); err != nil {
+			return "", errors.Wrap(err, "error while writing")
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", errors.Wrap(err, "error while writing")
+	}
+
+	fileName := uuid.NewString()
+
+	_, err := c.minio.PutObject(context.Background(), config.FileBucketName, fileName, &buf, int64(buf.Len()), minio.PutObjectOptions{ContentType: "text/csv"})
+	if err != nil {
+		return "", errors.Wrap(err, "error while upload file to minio")
+	}
+
+	return fmt.Sprintf("https://%s/%s/%s", c.cfg.MinioEndpoint, config.FileBucketName, fileName), nil
+}
@@ -0,0 +1,137 @@
+package listeners
+
+import (
+	"testing"
+)
+
+func TestParseImportNumber(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		value   string
+		decimal string
+		want    float32
+		err     bool
+	}{
+		{name: "integer", value: "1500", want: 1500},
+		{name: "spaces as thousands", value: "1 500 000", want: 1500000},
+		{name: "non-breaking space", value: "1\u00a0500,5", want: 1500.5},
+		{name: "both separators comma decimal", value: "1.500,25", want: 1500.25},
+		{name: "both separators dot decimal", value: "1,500.25", want: 1500.25},
+		{name: "repeated comma is thousands", value: "1,500,000", want: 1500000},
+		{name: "repeated dot is thousands", value: "1.500.000", want: 1500000},
+		{name: "single comma is decimal", value: "12,5", want: 12.5},
+		{name: "single dot is decimal", value: "12.50", want: 12.5},
+		{name: "three digits after comma is ambiguous", value: "1,500", err: true},
+		{name: "three digits after dot is ambiguous", value: "1.500", err: true},
+		{name: "comma decimal set", value: "1,500", decimal: ",", want: 1.5},
+		{name: "dot decimal set", value: "1,500", decimal: ".", want: 1500},
+		{name: "not a number", value: "abc", err: true},
+		{name: "negative", value: "-5", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportNumber(tt.value, tt.decimal)
+			if (err != nil) != tt.err {
+				t.Fatalf("parseImportNumber(%q, %q) error = %v, want error %v", tt.value, tt.decimal, err, tt.err)
+			}
+
+			if got != tt.want {
+				t.Errorf("parseImportNumber(%q, %q) = %v, want %v", tt.value, tt.decimal, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectImportDecimal(t *testing.T) {
+
+	table := func(values ...string) *importTable {
+		res := &importTable{}
+		for i, value := range values {
+			res.rows = append(res.rows, &importRow{
+				number: i + 2,
+				cells:  map[string]string{importRetailPrice: value},
+			})
+		}
+		return res
+	}
+
+	tests := []struct {
+		name  string
+		table *importTable
+		want  string
+	}{
+		{name: "no numbers", table: table(), want: ""},
+		{name: "integers only", table: table("100", "2 000"), want: ""},
+		{name: "ambiguous only", table: table("1,500", "2.000"), want: ""},
+		{name: "first unambiguous comma", table: table("1,500", "12,5", "1.5"), want: ","},
+		{name: "both separators", table: table("1.500,25"), want: ","},
+		{name: "repeated thousands", table: table("1,500,000"), want: "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectImportDecimal(tt.table); got != tt.want {
+				t.Errorf("detectImportDecimal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectImportCsvDelimiter(t *testing.T) {
+
+	tests := []struct {
+		name string
+		data string
+		want rune
+	}{
+		{name: "semicolon", data: "NAME;SKU;QUANTITY\nMilk;1;2,5", want: ';'},
+		{name: "comma", data: "NAME,SKU,QUANTITY\nMilk,1,2", want: ','},
+		{name: "tab", data: "NAME\tSKU\tQUANTITY\n", want: '\t'},
+		{name: "pipe", data: "NAME|SKU|QUANTITY", want: '|'},
+		{name: "quoted delimiters are ignored", data: "\"A;B;C\",SKU,QUANTITY\n", want: ','},
+		{name: "single column", data: "NAME\nMilk", want: ','},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectImportCsvDelimiter([]byte(tt.data)); got != tt.want {
+				t.Errorf("detectImportCsvDelimiter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeImportCsv(t *testing.T) {
+
+	// "Молоко" in cp1251
+	cp1251 := []byte{0xCC, 0xEE, 0xEB, 0xEE, 0xEA, 0xEE}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding string
+		want     string
+	}{
+		{name: "utf-8", data: []byte("Молоко"), want: "Молоко"},
+		{name: "utf-8 with BOM", data: append([]byte{0xEF, 0xBB, 0xBF}, "Молоко"...), want: "Молоко"},
+		{name: "BOM wins over encoding", data: append([]byte{0xEF, 0xBB, 0xBF}, "Молоко"...), encoding: importEncodingCP1251, want: "Молоко"},
+		{name: "invalid utf-8 is cp1251", data: cp1251, want: "Молоко"},
+		{name: "cp1251 set", data: cp1251, encoding: importEncodingCP1251, want: "Молоко"},
+		{name: "utf-8 set", data: []byte("Milk"), encoding: importEncodingUTF8, want: "Milk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeImportCsv(tt.data, tt.encoding)
+			if err != nil {
+				t.Fatalf("decodeImportCsv() error = %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("decodeImportCsv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package listeners
+
+import (
+	"context"
+	"genproto/catalog_service"
+
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+func (c *catalogService) GetDeadLetters(ctx context.Context, req *catalog_service.GetDeadLettersRequest) (*catalog_service.GetDeadLettersResponse, error) {
+
+	filter := models.GetDeadLettersRequest{
+		Limit:       req.Limit,
+		Page:        req.Page,
+		Topic:       req.Topic,
+		NotReplayed: req.NotReplayed,
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+
+	letters, err := c.strg.DeadLetter().GetAll(&filter)
+	if err != nil {
+		return nil, err
+	}
+
+	res := catalog_service.GetDeadLettersResponse{
+		Data:  make([]*catalog_service.DeadLetter, 0, len(letters.Data)),
+		Total: letters.Total,
+	}
+
+	for _, letter := range letters.Data {
+		res.Data = append(res.Data, &catalog_service.DeadLetter{
+			Id:         letter.Id,
+			Topic:      letter.Topic,
+			Partition:  letter.Partition,
+			Offset:     letter.Offset,
+			Key:        letter.Key,
+			Value:      letter.Value,
+			Headers:    letter.Headers,
+			Error:      letter.Error,
+			Attempts:   int32(letter.Attempts),
+			CreatedAt:  letter.CreatedAt,
+			ReplayedAt: letter.ReplayedAt,
+		})
+	}
+
+	return &res, nil
+}
+
+func (c *catalogService) ReplayDeadLetters(ctx context.Context, req *catalog_service.ReplayDeadLettersRequest) (*catalog_service.ReplayDeadLettersResponse, error) {
+
+	var res = catalog_service.ReplayDeadLettersResponse{
+		Failed: make([]string, 0),
+	}
+
+	if len(req.Ids) == 0 {
+		return nil, errors.New("ids are required")
+	}
+
+	letters, err := c.strg.DeadLetter().GetByIds(req.Ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, letter := range letters {
+		if err := c.kafka.Replay(letter); err != nil {
+			c.log.Error("error while replay dead letter", logger.String("id", letter.Id), logger.Error(err))
+			res.Failed = append(res.Failed, letter.Id)
+			continue
+		}
+
+		if err := c.strg.DeadLetter().MarkReplayed(letter.Id); err != nil {
+			return nil, err
+		}
+
+		res.Replayed++
+	}
+
+	return &res, nil
+}
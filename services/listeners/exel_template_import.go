@@ -1,10 +1,16 @@
 package listeners
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"genproto/catalog_service"
 	"genproto/common"
+	"strings"
 
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/pkg/errors"
@@ -115,3 +121,142 @@ func (c *catalogService) CreateExelTemplate(ctx context.Context, req *common.Req
 
 	return &res, nil
 }
+
+// ImportProductsFromExcel imports the file filled from CreateExelTemplate, bad rows are skipped and marked in the error file.
+// Dry run returns the counts and errors without the error file
+func (c *catalogService) ImportProductsFromExcel(ctx context.Context, in *catalog_service.ImportProductsRequest) (*catalog_service.ImportProductsResponse, error) {
+
+	c.log.Info("ImportProductsFromExcel", logger.Any("request", in))
+
+	if in.Request == nil || in.FileName == "" {
+		return nil, errors.New("request and file_name are required")
+	}
+
+	req := importRequestFromProto(in)
+
+	data, err := c.readImportFile(ctx, req.FileName)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "error while open excel file")
+	}
+
+	sheetName := f.GetSheetName(0)
+
+	table, err := readExcelImportTable(f, sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.importProducts(ctx, req, table)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Errors) > 0 && !req.DryRun {
+		res.ErrorFileUrl, err = c.uploadExcelImportErrors(f, sheetName, table, res.Errors)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return importResponseToProto(res), nil
+}
+
+// readExcelImportTable reads raw values of the sheet, they are not formatted by the cell number format
+// so numbers are always written with "." decimal separator and without thousands separator
+func readExcelImportTable(f *excelize.File, sheetName string) (*importTable, error) {
+
+	records, err := f.GetRows(sheetName, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "error while reading excel rows")
+	}
+
+	table, err := newImportTable(records, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	table.decimal = "."
+
+	return table, nil
+}
+
+// uploadExcelImportErrors marks bad cells with comments and adds ERRORS column to the imported file
+func (c *catalogService) uploadExcelImportErrors(f *excelize.File, sheetName string, table *importTable, rowErrors []*models.ImportRowError) (string, error) {
+
+	var (
+		cellErrors  = make(map[string][]string)
+		rowMessages = make(map[int][]string)
+		cells       = make([]string, 0)
+	)
+
+	style, err := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC7CE"}},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error while styling")
+	}
+
+	for _, rowError := range rowErrors {
+		rowMessages[rowError.Row] = append(rowMessages[rowError.Row], fmt.Sprintf("%s: %s", rowError.Column, rowError.Error))
+
+		index, ok := table.columns[rowError.Column]
+		if !ok {
+			continue
+		}
+
+		cell, err := excelize.CoordinatesToCellName(index+1, rowError.Row)
+		if err != nil {
+			return "", err
+		}
+
+		if _, ok := cellErrors[cell]; !ok {
+			cells = append(cells, cell)
+		}
+
+		cellErrors[cell] = append(cellErrors[cell], rowError.Error)
+	}
+
+	for _, cell := range cells {
+		if err := f.SetCellStyle(sheetName, cell, cell, style); err != nil {
+			return "", errors.Wrap(err, "error while styling bad cell")
+		}
+
+		if err := f.AddComment(sheetName, excelize.Comment{Author: "import", Cell: cell, Text: strings.Join(cellErrors[cell], "\n")}); err != nil {
+			return "", errors.Wrap(err, "error while adding comment")
+		}
+	}
+
+	errorsColumn, err := excelize.ColumnNumberToName(table.width + 1)
+	if err != nil {
+		return "", err
+	}
+
+	if err := f.SetCellValue(sheetName, errorsColumn+"1", "ERRORS"); err != nil {
+		return "", err
+	}
+
+	for row, messages := range rowMessages {
+		if err := f.SetCellValue(sheetName, fmt.Sprintf("%s%d", errorsColumn, row), strings.Join(messages, "; ")); err != nil {
+			return "", err
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return "", err
+	}
+
+	fileName := uuid.NewString()
+
+	_, err = c.minio.PutObject(context.Background(), config.FileBucketName, fileName, buf, -1, minio.PutObjectOptions{ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"})
+	if err != nil {
+		return "", errors.Wrap(err, "error while upload file to minio")
+	}
+
+	return fmt.Sprintf("https://%s/%s/%s", c.cfg.MinioEndpoint, config.FileBucketName, fileName), nil
+}
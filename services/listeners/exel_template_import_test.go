@@ -0,0 +1,70 @@
+package listeners
+
+import (
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadExcelImportTable(t *testing.T) {
+
+	f := excelize.NewFile()
+	sheetName := f.GetSheetName(0)
+
+	style, err := f.NewStyle(&excelize.Style{CustomNumFmt: &[]string{"#,##0.000"}[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := [][]interface{}{
+		{importName, importSku, importQuantity, importRetailPrice},
+		{"Milk", "1", 1.125, 2.75},
+		{"Bread", "2", 1500, 12500.5},
+		{"Salt", "3", "2.750", "12.5"},
+	}
+
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(sheetName, cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.SetCellStyle(sheetName, "C2", "D3", style); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := readExcelImportTable(f, sheetName)
+	if err != nil {
+		t.Fatalf("readExcelImportTable() error = %v", err)
+	}
+
+	want := []struct {
+		quantity float32
+		price    float32
+	}{
+		{quantity: 1.125, price: 2.75},
+		{quantity: 1500, price: 12500.5},
+		{quantity: 2.75, price: 12.5},
+	}
+
+	if len(table.rows) != len(want) {
+		t.Fatalf("readExcelImportTable() returned %d rows, want %d", len(table.rows), len(want))
+	}
+
+	for i, row := range table.rows {
+		quantity, err := parseImportNumber(row.value(importQuantity), table.decimal)
+		if err != nil {
+			t.Fatalf("row %d: parseImportNumber(%q) error = %v", row.number, row.value(importQuantity), err)
+		}
+
+		price, err := parseImportNumber(row.value(importRetailPrice), table.decimal)
+		if err != nil {
+			t.Fatalf("row %d: parseImportNumber(%q) error = %v", row.number, row.value(importRetailPrice), err)
+		}
+
+		if quantity != want[i].quantity || price != want[i].price {
+			t.Errorf("row %d = %v, %v, want %v, %v", row.number, quantity, price, want[i].quantity, want[i].price)
+		}
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"genproto/common"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/Invan2/invan_catalog_service/config"
 	"github.com/Invan2/invan_catalog_service/models"
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
@@ -53,56 +55,119 @@ type WriteCSVRowRequest struct {
 	CSVHeader         []string
 }
 
-func (c *catalogService) writeExcelRows(req WriteExcelRowRequest) error {
-	productsMap, err := c.elastic.Product().GetAllForExcel(req.ProductsFilterReq)
-	if err != nil {
-		return errors.Wrap(err, "error while getting products for excel")
+// productExportRow maps export headers to values of the product, shop values are keyed by "header(shop name)"
+func productExportRow(product *catalog_service.ProductES) map[string]interface{} {
+
+	var data = make(map[string]interface{})
+
+	data["product_id"] = product.Id
+	data["name"] = product.Name
+	data["sku"] = product.Sku
+	data["mxik_code"] = product.MxikCode
+	data["barcode"] = strings.Join(product.Barcodes, ", ")
+	data["category"] = ""
+
+	for _, category := range product.Categories {
+		data["category"] = fmt.Sprintf("%s%s, ", data["category"], category.Name)
 	}
 
-	for i, item := range productsMap.Data {
-		var row = make([]interface{}, 0)
+	for _, shop := range product.ShopPrices {
+		data[fmt.Sprintf("supply_price(%s)", shop.ShopName)] = shop.SupplyPrice
+		data[fmt.Sprintf("retail_price(%s)", shop.ShopName)] = shop.RetailPrice
+	}
 
-		for _, key := range req.ExeclHeaders {
-			row = append(row, item[key])
-		}
+	for _, measurementValue := range product.MeasurementValues {
+		data[fmt.Sprintf("amount(%s)", measurementValue.ShopName)] = measurementValue.Amount
+		data[fmt.Sprintf("low_stock(%s)", measurementValue.ShopName)] = measurementValue.SmallLeft
+	}
 
-		startCell, err := excelize.JoinCellName("A", i+2)
-		if err != nil {
-			return errors.Wrap(err, "error while startCell")
-		}
-		if err := req.File.SetSheetRow(req.SheetName, startCell, &row); err != nil {
-			return errors.Wrap(err, "error while setSheetRow")
+	return data
+}
+
+// writeExcelRows writes products page by page while they are scanned
+func (c *catalogService) writeExcelRows(ctx context.Context, req WriteExcelRowRequest) error {
+
+	var written int
+
+	err := c.elastic.Product().ScanAll(ctx, req.ProductsFilterReq, func(products []*catalog_service.ProductES, total int64) error {
+
+		for _, product := range products {
+			var (
+				item = productExportRow(product)
+				row  = make([]interface{}, 0, len(req.ExeclHeaders))
+			)
+
+			for _, key := range req.ExeclHeaders {
+				row = append(row, item[key])
+			}
+
+			startCell, err := excelize.JoinCellName("A", written+2)
+			if err != nil {
+				return errors.Wrap(err, "error while startCell")
+			}
+			if err := req.File.SetSheetRow(req.SheetName, startCell, &row); err != nil {
+				return errors.Wrap(err, "error while setSheetRow")
+			}
+
+			written++
 		}
+
+		reportJobProgress(ctx, 90*written/int(total))
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "error while writing products to excel")
 	}
+
 	return nil
 }
 
-func (c *catalogService) writeCSVRows(req WriteCSVRowRequest) error {
-	productsMap, err := c.elastic.Product().GetAllForCSV(req.ProductsFilterReq)
-	if err != nil {
-		return errors.Wrap(err, "error while getting products for excel")
-	}
+// writeCSVRows writes products page by page while they are scanned
+func (c *catalogService) writeCSVRows(ctx context.Context, req WriteCSVRowRequest) error {
+
+	var written int
 
-	for _, item := range productsMap.Data {
-		var row = make([]string, 0)
+	err := c.elastic.Product().ScanAll(ctx, req.ProductsFilterReq, func(products []*catalog_service.ProductES, total int64) error {
 
-		for _, key := range req.CSVHeader {
-			val := item[key]
-			if val == nil {
-				row = append(row, "0")
-			} else {
-				row = append(row, fmt.Sprintf("%v", val))
+		for _, product := range products {
+			var (
+				item = productExportRow(product)
+				row  = make([]string, 0, len(req.CSVHeader))
+			)
+
+			for _, key := range req.CSVHeader {
+				val := item[key]
+				if val == nil {
+					row = append(row, "0")
+				} else {
+					row = append(row, fmt.Sprintf("%v", val))
+				}
 			}
-		}
 
-		if err := req.File.Write(row); err != nil {
-			return errors.Wrap(err, "error while Writing")
+			if err := req.File.Write(row); err != nil {
+				return errors.Wrap(err, "error while Writing")
+			}
+
+			written++
 		}
 
+		reportJobProgress(ctx, 90*written/int(total))
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "error while writing products to csv")
 	}
+
 	return nil
 }
 
+// CreateProductExelTemplateJob returns id of the export job, the file url is result of the job
+func (c *catalogService) CreateProductExelTemplateJob(ctx context.Context, req *catalog_service.GetProductExcelDownloadRequest) (*common.ResponseID, error) {
+	return c.createJob(req.GetRequest(), config.JobProductExcelExport, req)
+}
+
 func (c *catalogService) CreateProductExelTemplate(ctx context.Context, req *catalog_service.GetProductExcelDownloadRequest) (*common.ResponseID, error) {
 	var (
 		excelFileName = "Sheet1"
@@ -167,13 +232,11 @@ func (c *catalogService) CreateProductExelTemplate(ctx context.Context, req *cat
 	}
 
 	if req.ExportType == "data" {
-		err = c.writeExcelRows(WriteExcelRowRequest{
+		err = c.writeExcelRows(ctx, WriteExcelRowRequest{
 			File:         f,
 			SheetName:    sheetName,
 			ExeclHeaders: excelHeader,
 			ProductsFilterReq: &catalog_service.GetAllProductsRequest{
-				Limit:      10000,
-				Page:       1,
 				Search:     "",
 				ShopIds:    req.ShopIds,
 				Request:    req.Request,
@@ -188,13 +251,11 @@ func (c *catalogService) CreateProductExelTemplate(ctx context.Context, req *cat
 	}
 
 	if req.ExportType == "all" {
-		err = c.writeExcelRows(WriteExcelRowRequest{
+		err = c.writeExcelRows(ctx, WriteExcelRowRequest{
 			File:         f,
 			SheetName:    sheetName,
 			ExeclHeaders: excelHeader,
 			ProductsFilterReq: &catalog_service.GetAllProductsRequest{
-				Limit:      10000,
-				Page:       1,
 				Search:     "",
 				ShopIds:    req.ShopIds,
 				Request:    req.Request,
@@ -228,6 +289,11 @@ func (c *catalogService) CreateProductExelTemplate(ctx context.Context, req *cat
 	return &res, nil
 }
 
+// CreateProductCsvTemplateJob returns id of the export job, the file url is result of the job
+func (c *catalogService) CreateProductCsvTemplateJob(ctx context.Context, req *catalog_service.GetProductCsvDownloadRequest) (*common.ResponseID, error) {
+	return c.createJob(req.GetRequest(), config.JobProductCsvExport, req)
+}
+
 func (c *catalogService) CreateProductCsvTemplate(ctx context.Context, req *catalog_service.GetProductCsvDownloadRequest) (*common.ResponseID, error) {
 	var (
 		res        common.ResponseID
@@ -287,12 +353,10 @@ func (c *catalogService) CreateProductCsvTemplate(ctx context.Context, req *cata
 	}
 
 	if req.ExportType == "data" {
-		err = c.writeCSVRows(WriteCSVRowRequest{
+		err = c.writeCSVRows(ctx, WriteCSVRowRequest{
 			File:      w,
 			CSVHeader: csvHeader,
 			ProductsFilterReq: &catalog_service.GetAllProductsRequest{
-				Limit:      10000,
-				Page:       1,
 				Search:     "",
 				ShopIds:    req.ShopIds,
 				Request:    req.Request,
@@ -307,12 +371,10 @@ func (c *catalogService) CreateProductCsvTemplate(ctx context.Context, req *cata
 	}
 
 	if req.ExportType == "all" {
-		err = c.writeCSVRows(WriteCSVRowRequest{
+		err = c.writeCSVRows(ctx, WriteCSVRowRequest{
 			File:      w,
 			CSVHeader: csvHeader,
 			ProductsFilterReq: &catalog_service.GetAllProductsRequest{
-				Limit:      10000,
-				Page:       1,
 				Search:     "",
 				ShopIds:    req.ShopIds,
 				Filters:    req.Filters,
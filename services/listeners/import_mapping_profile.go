@@ -0,0 +1,145 @@
+package listeners
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"genproto/catalog_service"
+	"genproto/common"
+	"strings"
+
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+func validateImportMappingProfile(profile *models.ImportMappingProfile) error {
+
+	if profile.CompanyId == "" || strings.TrimSpace(profile.Name) == "" {
+		return errors.New("company_id and name are required")
+	}
+
+	for header, column := range profile.Mapping {
+		if !isImportColumn(column) {
+			return fmt.Errorf("unknown column %q of header %q", column, header)
+		}
+	}
+
+	return validateImportCsvOptions(profile.Encoding, profile.Delimiter, profile.DecimalSeparator)
+}
+
+func importMappingProfileToProto(profile *models.ImportMappingProfile) *catalog_service.ImportMappingProfile {
+	return &catalog_service.ImportMappingProfile{
+		Id:               profile.Id,
+		CompanyId:        profile.CompanyId,
+		Name:             profile.Name,
+		Mapping:          profile.Mapping,
+		Encoding:         profile.Encoding,
+		Delimiter:        profile.Delimiter,
+		DecimalSeparator: profile.DecimalSeparator,
+		CreatedBy:        profile.CreatedBy,
+		CreatedAt:        profile.CreatedAt,
+		UpdatedAt:        profile.UpdatedAt,
+	}
+}
+
+func (c *catalogService) CreateImportMappingProfile(ctx context.Context, req *catalog_service.CreateImportMappingProfileRequest) (*common.ResponseID, error) {
+
+	c.log.Info("CreateImportMappingProfile", logger.Any("request", req))
+
+	profile := models.ImportMappingProfile{
+		CompanyId:        req.GetRequest().GetCompanyId(),
+		Name:             req.Name,
+		Mapping:          req.Mapping,
+		Encoding:         strings.ToLower(req.Encoding),
+		Delimiter:        req.Delimiter,
+		DecimalSeparator: req.DecimalSeparator,
+		CreatedBy:        req.GetRequest().GetUserId(),
+	}
+
+	if err := validateImportMappingProfile(&profile); err != nil {
+		return nil, err
+	}
+
+	if err := c.strg.ImportMappingProfile().Create(&profile); err != nil {
+		return nil, err
+	}
+
+	return &common.ResponseID{Id: profile.Id}, nil
+}
+
+func (c *catalogService) UpdateImportMappingProfile(ctx context.Context, req *catalog_service.UpdateImportMappingProfileRequest) (*common.ResponseID, error) {
+
+	c.log.Info("UpdateImportMappingProfile", logger.Any("request", req))
+
+	profile := models.ImportMappingProfile{
+		Id:               req.Id,
+		CompanyId:        req.GetRequest().GetCompanyId(),
+		Name:             req.Name,
+		Mapping:          req.Mapping,
+		Encoding:         strings.ToLower(req.Encoding),
+		Delimiter:        req.Delimiter,
+		DecimalSeparator: req.DecimalSeparator,
+	}
+
+	if err := validateImportMappingProfile(&profile); err != nil {
+		return nil, err
+	}
+
+	err := c.strg.ImportMappingProfile().Update(&profile)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("import mapping profile not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.ResponseID{Id: req.Id}, nil
+}
+
+func (c *catalogService) GetImportMappingProfile(ctx context.Context, req *common.RequestID) (*catalog_service.ImportMappingProfile, error) {
+
+	profile, err := c.strg.ImportMappingProfile().GetById(req.Id, req.GetRequest().GetCompanyId())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("import mapping profile not found")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return importMappingProfileToProto(profile), nil
+}
+
+func (c *catalogService) GetImportMappingProfiles(ctx context.Context, req *common.Request) (*catalog_service.GetImportMappingProfilesResponse, error) {
+
+	profiles, err := c.strg.ImportMappingProfile().GetAll(req.GetCompanyId())
+	if err != nil {
+		return nil, err
+	}
+
+	res := catalog_service.GetImportMappingProfilesResponse{
+		Data: make([]*catalog_service.ImportMappingProfile, 0, len(profiles)),
+	}
+
+	for _, profile := range profiles {
+		res.Data = append(res.Data, importMappingProfileToProto(profile))
+	}
+
+	return &res, nil
+}
+
+func (c *catalogService) DeleteImportMappingProfile(ctx context.Context, req *common.RequestID) (*common.ResponseID, error) {
+
+	c.log.Info("DeleteImportMappingProfile", logger.Any("request", req))
+
+	err := c.strg.ImportMappingProfile().Delete(req.Id, req.GetRequest().GetCompanyId())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("import mapping profile not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.ResponseID{Id: req.Id}, nil
+}
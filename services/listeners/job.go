@@ -0,0 +1,343 @@
+package listeners
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"genproto/catalog_service"
+	"genproto/common"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// jobHandler runs the job and returns url of the result file
+type jobHandler func(ctx context.Context, payload []byte) (string, error)
+
+type jobProgressKey struct{}
+
+// reportJobProgress sets progress of the job running with ctx, does nothing outside of jobs
+func reportJobProgress(ctx context.Context, progress int) {
+	if progressFn, ok := ctx.Value(jobProgressKey{}).(func(int)); ok {
+		progressFn(progress)
+	}
+}
+
+func (c *catalogService) jobHandlers() map[string]jobHandler {
+	return map[string]jobHandler{
+		config.JobProductExcelExport: func(ctx context.Context, payload []byte) (string, error) {
+			var req catalog_service.GetProductExcelDownloadRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return "", errors.Wrap(err, "error while unmarshal job payload")
+			}
+
+			res, err := c.CreateProductExelTemplate(ctx, &req)
+			if err != nil {
+				return "", err
+			}
+
+			return res.Id, nil
+		},
+		config.JobProductCsvExport: func(ctx context.Context, payload []byte) (string, error) {
+			var req catalog_service.GetProductCsvDownloadRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return "", errors.Wrap(err, "error while unmarshal job payload")
+			}
+
+			res, err := c.CreateProductCsvTemplate(ctx, &req)
+			if err != nil {
+				return "", err
+			}
+
+			return res.Id, nil
+		},
+		config.JobProductLabels: func(ctx context.Context, payload []byte) (string, error) {
+			var req catalog_service.GetProductLabelsRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return "", errors.Wrap(err, "error while unmarshal job payload")
+			}
+
+			res, err := c.BulkGenerateProductLabels(ctx, &req)
+			if err != nil {
+				return "", err
+			}
+
+			return res.Id, nil
+		},
+		config.JobScalesFile: func(ctx context.Context, payload []byte) (string, error) {
+			var req catalog_service.GetScalesTemplateByIDRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return "", errors.Wrap(err, "error while unmarshal job payload")
+			}
+
+			res, err := c.GetScalesTemplateByID(ctx, &req)
+			if err != nil {
+				return "", err
+			}
+
+			return res.Url, nil
+		},
+		config.JobProductBulkUpdate: func(ctx context.Context, payload []byte) (string, error) {
+			var req catalog_service.ProductBulkOperationRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return "", errors.Wrap(err, "error while unmarshal job payload")
+			}
+
+			_, err := c.BulkUpdateProduct(ctx, &req)
+			return "", err
+		},
+	}
+}
+
+// createJob saves the job to be run by the workers and returns its id
+func (c *catalogService) createJob(request *common.Request, jobType string, payload interface{}) (*common.ResponseID, error) {
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while marshal job payload")
+	}
+
+	job := models.Job{
+		CompanyId: request.GetCompanyId(),
+		Type:      jobType,
+		Payload:   data,
+		CreatedBy: request.GetUserId(),
+	}
+
+	if err := c.strg.Job().Create(&job); err != nil {
+		return nil, err
+	}
+
+	c.log.Info("job created", logger.String("id", job.Id), logger.String("type", jobType))
+
+	select {
+	case c.jobKick <- struct{}{}:
+	default:
+	}
+
+	return &common.ResponseID{Id: job.Id}, nil
+}
+
+// RunJobs runs JobWorkers workers until ctx is done, jobs interrupted by shutdown are taken again after JobStaleTimeout
+func (c *catalogService) RunJobs(ctx context.Context) {
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < c.cfg.JobWorkers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			c.runJobWorker(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (c *catalogService) runJobWorker(ctx context.Context) {
+
+	ticker := time.NewTicker(c.cfg.JobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			job, err := c.strg.Job().Claim(c.cfg.JobStaleTimeout)
+			if err != nil {
+				c.log.Error("error while claim job", logger.Error(err))
+				break
+			}
+
+			if job == nil {
+				break
+			}
+
+			c.runJob(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.jobKick:
+		}
+	}
+}
+
+func (c *catalogService) runJob(ctx context.Context, job *models.Job) {
+
+	var (
+		progress  int64
+		resultUrl string
+		err       error
+		done      = make(chan struct{})
+	)
+
+	c.log.Info("job started", logger.String("id", job.Id), logger.String("type", job.Type))
+
+	jobCtx, cancel := context.WithCancel(context.WithValue(ctx, jobProgressKey{}, func(value int) {
+		atomic.StoreInt64(&progress, int64(value))
+	}))
+	defer cancel()
+
+	// heartbeat keeps the job from being taken by another worker and delivers cancellation
+	go func() {
+		ticker := time.NewTicker(c.cfg.JobPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			cancelRequested, err := c.strg.Job().UpdateProgress(job.Id, int(atomic.LoadInt64(&progress)))
+			if err != nil {
+				c.log.Error("error while update job progress", logger.String("id", job.Id), logger.Error(err))
+				continue
+			}
+
+			if cancelRequested {
+				cancel()
+			}
+		}
+	}()
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+
+		if job.CancelRequested {
+			err = context.Canceled
+			cancel()
+			return
+		}
+
+		handler, ok := c.jobHandlers()[job.Type]
+		if !ok {
+			err = fmt.Errorf("unknown job type: %s", job.Type)
+			return
+		}
+
+		resultUrl, err = handler(jobCtx, job.Payload)
+	}()
+
+	close(done)
+
+	status := config.JobSucceeded
+	switch {
+	case ctx.Err() != nil:
+		// shutdown, job stays running and is taken again when it becomes stale
+		c.log.Warn("job interrupted", logger.String("id", job.Id))
+		return
+	case jobCtx.Err() != nil:
+		status = config.JobCancelled
+	case err != nil:
+		status = config.JobFailed
+	}
+
+	errMessage := ""
+	if err != nil && status == config.JobFailed {
+		errMessage = err.Error()
+		c.log.Error("job failed", logger.String("id", job.Id), logger.Error(err))
+	}
+
+	if err := c.strg.Job().Finish(job.Id, status, resultUrl, errMessage); err != nil {
+		c.log.Error("error while finish job", logger.String("id", job.Id), logger.Error(err))
+		return
+	}
+
+	c.log.Info("job finished", logger.String("id", job.Id), logger.String("status", status))
+}
+
+func jobToProto(job *models.Job) *catalog_service.Job {
+	return &catalog_service.Job{
+		Id:              job.Id,
+		CompanyId:       job.CompanyId,
+		Type:            job.Type,
+		Status:          job.Status,
+		Progress:        int32(job.Progress),
+		ResultUrl:       job.ResultUrl,
+		Error:           job.Error,
+		CancelRequested: job.CancelRequested,
+		CreatedBy:       job.CreatedBy,
+		CreatedAt:       job.CreatedAt,
+		StartedAt:       job.StartedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+}
+
+func (c *catalogService) GetJob(ctx context.Context, req *common.RequestID) (*catalog_service.Job, error) {
+
+	job, err := c.strg.Job().GetById(req.Id, req.GetRequest().GetCompanyId())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("job not found")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return jobToProto(job), nil
+}
+
+func (c *catalogService) ListJobs(ctx context.Context, req *catalog_service.GetJobsRequest) (*catalog_service.GetJobsResponse, error) {
+
+	filter := models.GetJobsRequest{
+		CompanyId: req.GetRequest().GetCompanyId(),
+		Limit:     req.Limit,
+		Page:      req.Page,
+		Type:      req.Type,
+		Status:    req.Status,
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+
+	jobs, err := c.strg.Job().GetAll(&filter)
+	if err != nil {
+		return nil, err
+	}
+
+	res := catalog_service.GetJobsResponse{
+		Data:  make([]*catalog_service.Job, 0, len(jobs.Data)),
+		Total: jobs.Total,
+	}
+
+	for _, job := range jobs.Data {
+		res.Data = append(res.Data, jobToProto(job))
+	}
+
+	return &res, nil
+}
+
+func (c *catalogService) CancelJob(ctx context.Context, req *common.RequestID) (*catalog_service.Job, error) {
+
+	c.log.Info("CancelJob", logger.Any("request", req))
+
+	job, err := c.strg.Job().Cancel(req.Id, req.GetRequest().GetCompanyId())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("job not found")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return jobToProto(job), nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/Invan2/invan_catalog_service/config"
 	"github.com/Invan2/invan_catalog_service/events"
+	"github.com/Invan2/invan_catalog_service/models"
 	"github.com/Invan2/invan_catalog_service/pkg/logger"
 	"github.com/Invan2/invan_catalog_service/storage"
 	"github.com/minio/minio-go/v7"
@@ -23,6 +24,7 @@ type catalogService struct {
 	minio   *minio.Client
 	cfg     *config.Config
 	pdf     pdfmaker.PdFMakekerI
+	jobKick chan struct{}
 }
 
 type CatalogService interface {
@@ -35,10 +37,15 @@ type CatalogService interface {
 	GetAllProducts(ctx context.Context, req *catalog_service.GetAllProductsRequest) (*catalog_service.GetAllProductsResponse, error)
 	DeleteProductById(ctx context.Context, req *common.RequestID) (*common.ResponseID, error)
 	SearchProducts(ctx context.Context, req *catalog_service.GetAllProductsRequest) (*catalog_service.SearchProductsResponse, error)
+	GetAllProductsByCursor(ctx context.Context, req *catalog_service.GetAllProductsByCursorRequest) (*catalog_service.GetAllProductsByCursorResponse, error)
+	SuggestProducts(ctx context.Context, req *catalog_service.SuggestProductsRequest) (*catalog_service.SuggestProductsResponse, error)
+	GetValuation(ctx context.Context, req *catalog_service.GetValuationRequest) (*catalog_service.GetValuationResponse, error)
 	DeleteProductsByIds(ctx context.Context, req *common.RequestIDs) (*common.Empty, error)
 	BulkUpdateProduct(ctx context.Context, req *catalog_service.ProductBulkOperationRequest) (*common.ResponseID, error)
+	BulkUpdateProductJob(ctx context.Context, req *catalog_service.ProductBulkOperationRequest) (*common.ResponseID, error)
 
 	BulkGenerateProductLabels(ctx context.Context, req *catalog_service.GetProductLabelsRequest) (*common.ResponseID, error)
+	BulkGenerateProductLabelsJob(ctx context.Context, req *catalog_service.GetProductLabelsRequest) (*common.ResponseID, error)
 
 	// measurementUnit
 	CreateMeasurementUnit(ctx context.Context, req *catalog_service.CreateMeasurementUnitRequest) (*common.ResponseID, error)
@@ -68,11 +75,21 @@ type CatalogService interface {
 	CreateExelTemplate(ctx context.Context, req *common.Request) (*common.ResponseID, error)
 	CreateProductExelTemplate(ctx context.Context, req *catalog_service.GetProductExcelDownloadRequest) (*common.ResponseID, error)
 	CreateProductCsvTemplate(ctx context.Context, req *catalog_service.GetProductCsvDownloadRequest) (*common.ResponseID, error)
+	CreateProductExelTemplateJob(ctx context.Context, req *catalog_service.GetProductExcelDownloadRequest) (*common.ResponseID, error)
+	CreateProductCsvTemplateJob(ctx context.Context, req *catalog_service.GetProductCsvDownloadRequest) (*common.ResponseID, error)
+	ImportProductsFromExcel(ctx context.Context, req *catalog_service.ImportProductsRequest) (*catalog_service.ImportProductsResponse, error)
+	ImportProductsFromCsv(ctx context.Context, req *catalog_service.ImportProductsCsvRequest) (*catalog_service.ImportProductsResponse, error)
+	CreateImportMappingProfile(ctx context.Context, req *catalog_service.CreateImportMappingProfileRequest) (*common.ResponseID, error)
+	UpdateImportMappingProfile(ctx context.Context, req *catalog_service.UpdateImportMappingProfileRequest) (*common.ResponseID, error)
+	GetImportMappingProfile(ctx context.Context, req *common.RequestID) (*catalog_service.ImportMappingProfile, error)
+	GetImportMappingProfiles(ctx context.Context, req *common.Request) (*catalog_service.GetImportMappingProfilesResponse, error)
+	DeleteImportMappingProfile(ctx context.Context, req *common.RequestID) (*common.ResponseID, error)
 
 	// Scales_template
 	CreateScalesTemplates(context.Context, *catalog_service.CreateScalesTemplateRequest) (*common.ResponseID, error)
 	GetScalesTemplateByID(context.Context, *catalog_service.GetScalesTemplateByIDRequest) (*catalog_service.ScalesTemplate, error)
 	GetAllScalesTemplates(context.Context, *catalog_service.GetAllScalesTemplatesRequest) (*catalog_service.GetAllScalesTemplatesResponse, error)
+	GenerateScalesFile(ctx context.Context, req *catalog_service.GetScalesTemplateByIDRequest) (*common.ResponseID, error)
 
 	// VAT
 	CreateVat(ctx context.Context, req *catalog_service.CreateVatRequest) (*common.ResponseID, error)
@@ -80,6 +97,19 @@ type CatalogService interface {
 	UpdateVatById(ctx context.Context, req *catalog_service.UpdateVatRequest) (*common.ResponseID, error)
 	GetAllVats(ctx context.Context, req *common.SearchRequest) (*catalog_service.GetAllVatsResponse, error)
 	DeleteVat(ctx context.Context, req *common.RequestID) (*common.ResponseID, error)
+
+	// jobs
+	GetJob(ctx context.Context, req *common.RequestID) (*catalog_service.Job, error)
+	ListJobs(ctx context.Context, req *catalog_service.GetJobsRequest) (*catalog_service.GetJobsResponse, error)
+	CancelJob(ctx context.Context, req *common.RequestID) (*catalog_service.Job, error)
+	RunJobs(ctx context.Context)
+
+	// dead letter (admin)
+	GetDeadLetters(ctx context.Context, req *catalog_service.GetDeadLettersRequest) (*catalog_service.GetDeadLettersResponse, error)
+	ReplayDeadLetters(ctx context.Context, req *catalog_service.ReplayDeadLettersRequest) (*catalog_service.ReplayDeadLettersResponse, error)
+	ReplayCatalog(ctx context.Context, req *catalog_service.ReplayCatalogRequest) (*catalog_service.ReplayCatalogResponse, error)
+	ReindexProducts(ctx context.Context, req *models.ReindexProductsRequest) (*models.ReindexProductsResponse, error)
+	CheckConsistency(ctx context.Context, req *catalog_service.CheckConsistencyRequest) (*catalog_service.CheckConsistencyResponse, error)
 }
 
 func NewCatalogService(log logger.Logger, kafka events.PubSubServer, strg storage.StoragePg, elastic storage.StorageES, minio *minio.Client, cfg *config.Config) CatalogService {
@@ -91,5 +121,6 @@ func NewCatalogService(log logger.Logger, kafka events.PubSubServer, strg storag
 		minio:   minio,
 		cfg:     cfg,
 		pdf:     pdfmaker.NewPdfMaker(log, minio, cfg),
+		jobKick: make(chan struct{}, 1),
 	}
 }
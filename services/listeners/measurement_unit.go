@@ -32,7 +32,7 @@ func (c *catalogService) CreateMeasurementUnit(ctx context.Context, req *catalog
 		return nil, err
 	}
 
-	err = c.kafka.Push("v1.catalog_service.measurement_unit.created.success", common.MeasurementUnitCopyRequest{
+	_, err = tr.Outbox().Create("v1.catalog_service.measurement_unit.created.success", measurementUnit.Id, &common.MeasurementUnitCopyRequest{
 		Id:                   measurementUnit.Id,
 		CompanyId:            req.Request.CompanyId,
 		IsDeletable:          measurementUnit.IsDeletable,
@@ -86,7 +86,7 @@ func (c *catalogService) UpdateMeasurementUnit(ctx context.Context, req *catalog
 		return nil, err
 	}
 
-	err = c.kafka.Push("v1.catalog_service.measurement_unit.created.success", common.MeasurementUnitCopyRequest{
+	_, err = tr.Outbox().Create("v1.catalog_service.measurement_unit.created.success", measurementUnit.Id, &common.MeasurementUnitCopyRequest{
 		Id:                   measurementUnit.Id,
 		CompanyId:            req.Request.CompanyId,
 		IsDeletable:          measurementUnit.IsDeletable,
@@ -14,6 +14,21 @@ import (
 
 func (c *catalogService) CreateProduct(ctx context.Context, req *catalog_service.CreateProductRequest) (*common.ResponseID, error) {
 
+	res, outboxId, err := c.createProduct(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// the change is already committed, the event will be published by the outbox relay anyway
+	if err := c.kafka.WaitOutbox(ctx, outboxId); err != nil {
+		return nil, errors.Wrap(err, "error while publishing product created event")
+	}
+
+	return res, nil
+}
+
+func (c *catalogService) createProduct(ctx context.Context, req *catalog_service.CreateProductRequest) (*common.ResponseID, int64, error) {
+
 	var (
 		measurementValues      = make(map[string]*catalog_service.ShopMeasurementValue, 0)
 		shopPrices             = make(map[string]*catalog_service.ShopPrice, 0)
@@ -22,22 +37,22 @@ func (c *catalogService) CreateProduct(ctx context.Context, req *catalog_service
 
 	measurementUnit, err := c.strg.MeasurementUnit().GetByID(&common.RequestID{Id: req.MeasurementUnitId, Request: req.Request})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	supplier, err := c.strg.Supplier().GetById(&common.RequestID{Id: req.SupplierId, Request: req.Request})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	vat, err := c.strg.Vat().GetById(ctx, &common.RequestID{Id: req.VatId, Request: req.Request})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	tr, err := c.strg.WithTransaction()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	defer func() {
@@ -50,17 +65,27 @@ func (c *catalogService) CreateProduct(ctx context.Context, req *catalog_service
 
 	productId, _, err := tr.Product().Create(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	shopNames, err := c.strg.Shop().GetCompanyAllShopNames(req.Request)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	categories, err := c.strg.Category().GetShortCategoriesByIds(req.CategoryIds)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	brand, err := c.strg.Product().GetShortBrand(req.BrandId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tags, err := c.strg.Product().GetShortTagsByIds(req.TagIds)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	for _, value := range req.ShopMeasurementValues {
@@ -116,6 +141,8 @@ func (c *catalogService) CreateProduct(ctx context.Context, req *catalog_service
 		Image:             "",
 		MeasurementValues: measurementValues,
 		Categories:        categories,
+		Brand:             brand,
+		Tags:              tags,
 		ShopPrices:        shopPrices,
 		CreatedAt:         time.Now().Format(config.DateTimeFormat),
 		UpdatedAt:         float64(time.Now().UnixMilli()),
@@ -138,7 +165,7 @@ func (c *catalogService) CreateProduct(ctx context.Context, req *catalog_service
 		})
 	}
 
-	err = c.kafka.Push("v1.catalog_service.product.created.success", common.CreateProductCopyRequest{
+	outboxId, err := tr.Outbox().Create("v1.catalog_service.product.created.success", productId, &common.CreateProductCopyRequest{
 		Id:                    productId,
 		IsMarking:             req.IsMarking,
 		Sku:                   req.Sku,
@@ -157,15 +184,15 @@ func (c *catalogService) CreateProduct(ctx context.Context, req *catalog_service
 		Request:               req.Request,
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "error while creating product")
+		return nil, 0, errors.Wrap(err, "error while creating product")
 	}
 
 	err = c.elastic.Product().Create(productEs)
 	if err != nil {
-		return nil, errors.Wrap(err, "error while creating product. Elastic")
+		return nil, 0, errors.Wrap(err, "error while creating product. Elastic")
 	}
 
-	return &common.ResponseID{Id: productId}, nil
+	return &common.ResponseID{Id: productId}, outboxId, nil
 }
 
 func (c *catalogService) GetProductByID(ctx context.Context, req *common.RequestID) (*catalog_service.Product, error) {
@@ -174,6 +201,20 @@ func (c *catalogService) GetProductByID(ctx context.Context, req *common.Request
 
 func (c *catalogService) UpdateProduct(ctx context.Context, req *catalog_service.UpdateProductRequest) (*common.ResponseID, error) {
 
+	res, outboxId, err := c.updateProduct(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.kafka.WaitOutbox(ctx, outboxId); err != nil {
+		return nil, errors.Wrap(err, "error while publishing product updated event")
+	}
+
+	return res, nil
+}
+
+func (c *catalogService) updateProduct(ctx context.Context, req *catalog_service.UpdateProductRequest) (*common.ResponseID, int64, error) {
+
 	var (
 		shopMeasurementValues  = make(map[string]*catalog_service.ShopMeasurementValue)
 		shopPrices             = make(map[string]*catalog_service.ShopPrice)
@@ -181,7 +222,7 @@ func (c *catalogService) UpdateProduct(ctx context.Context, req *catalog_service
 	)
 	tr, err := c.strg.WithTransaction()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	defer func() {
@@ -194,22 +235,22 @@ func (c *catalogService) UpdateProduct(ctx context.Context, req *catalog_service
 
 	res, err := tr.Product().Update(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	measurementUnit, err := c.strg.MeasurementUnit().GetByID(&common.RequestID{Id: req.MeasurementUnitId, Request: req.Request})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	supplier, err := c.strg.Supplier().GetById(&common.RequestID{Id: req.SupplierId, Request: req.Request})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	vat, err := c.strg.Vat().GetById(ctx, &common.RequestID{Id: req.VatId, Request: req.Request})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	for _, measurementValues := range req.MeasurementValues {
@@ -237,7 +278,17 @@ func (c *catalogService) UpdateProduct(ctx context.Context, req *catalog_service
 
 	categories, err := tr.Category().GetShortCategoriesByIds(req.CategoryIds)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	brand, err := tr.Product().GetShortBrand(req.BrandId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tags, err := tr.Product().GetShortTagsByIds(req.TagIds)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	productEs := &catalog_service.ProductES{
@@ -270,6 +321,8 @@ func (c *catalogService) UpdateProduct(ctx context.Context, req *catalog_service
 		Image:             "",
 		MeasurementValues: shopMeasurementValues,
 		Categories:        categories,
+		Brand:             brand,
+		Tags:              tags,
 		ShopPrices:        shopPrices,
 		// CreatedAt:         time.Now().Format(config.DateTimeFormat),
 		UpdatedAt: float64(time.Now().UnixMilli()),
@@ -294,7 +347,7 @@ func (c *catalogService) UpdateProduct(ctx context.Context, req *catalog_service
 		})
 	}
 
-	err = c.kafka.Push("v1.catalog_service.product.created.success", &common.CreateProductCopyRequest{
+	outboxId, err := tr.Outbox().Create("v1.catalog_service.product.created.success", req.Id, &common.CreateProductCopyRequest{
 		Id:                    req.Id,
 		Sku:                   req.Sku,
 		Name:                  req.Name,
@@ -313,20 +366,89 @@ func (c *catalogService) UpdateProduct(ctx context.Context, req *catalog_service
 		Request:               req.Request,
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "error while updating product")
+		return nil, 0, errors.Wrap(err, "error while updating product")
 	}
 
 	err = c.elastic.Product().Update(productEs)
 	if err != nil {
-		return nil, errors.Wrap(err, "error while updating product Elastic")
+		return nil, 0, errors.Wrap(err, "error while updating product Elastic")
 	}
 
-	return res, nil
+	return res, outboxId, nil
 }
 
 func (c *catalogService) GetAllProducts(ctx context.Context, req *catalog_service.GetAllProductsRequest) (*catalog_service.GetAllProductsResponse, error) {
 	c.log.Info("GetAllProducts", logger.Any("request", req))
-	return c.elastic.Product().GetAll(req)
+
+	res, err := c.elastic.Product().GetAll(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.setFacetNames(res.Facets); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// setFacetNames sets names of category and tags buckets, a product has several of them so elastic can not name a bucket
+func (c *catalogService) setFacetNames(facets []*catalog_service.Facet) error {
+
+	for _, facet := range facets {
+		if len(facet.Buckets) == 0 {
+			continue
+		}
+
+		var (
+			ids   = make([]string, 0, len(facet.Buckets))
+			names = make(map[string]string)
+		)
+
+		for _, bucket := range facet.Buckets {
+			ids = append(ids, bucket.Id)
+		}
+
+		switch facet.Name {
+		case "category":
+			categories, err := c.strg.Category().GetShortCategoriesByIds(ids)
+			if err != nil {
+				return err
+			}
+
+			for _, category := range categories {
+				names[category.Id] = category.Name
+			}
+		case "tags":
+			tags, err := c.strg.Product().GetShortTagsByIds(ids)
+			if err != nil {
+				return err
+			}
+
+			for _, tag := range tags {
+				names[tag.Id] = tag.Name
+			}
+		default:
+			continue
+		}
+
+		for _, bucket := range facet.Buckets {
+			bucket.Name = names[bucket.Id]
+		}
+	}
+
+	return nil
+}
+
+// GetAllProductsByCursor pages through GetAllProducts result without max_result_window limit
+func (c *catalogService) GetAllProductsByCursor(ctx context.Context, req *catalog_service.GetAllProductsByCursorRequest) (*catalog_service.GetAllProductsByCursorResponse, error) {
+	c.log.Info("GetAllProductsByCursor", logger.Any("request", req))
+
+	if req.Products == nil {
+		return nil, errors.New("products request is required")
+	}
+
+	return c.elastic.Product().GetAllByCursor(ctx, req)
 }
 
 func (c *catalogService) DeleteProductsByIds(ctx context.Context, req *common.RequestIDs) (*common.Empty, error) {
@@ -390,8 +512,55 @@ func (c *catalogService) SearchProducts(ctx context.Context, req *catalog_servic
 	return c.elastic.Product().SearchProducts(req)
 }
 
+func (c *catalogService) SuggestProducts(ctx context.Context, req *catalog_service.SuggestProductsRequest) (*catalog_service.SuggestProductsResponse, error) {
+
+	if req.Request == nil || req.Request.CompanyId == "" {
+		return nil, errors.New("company_id is required")
+	}
+
+	if req.Prefix == "" {
+		return &catalog_service.SuggestProductsResponse{Suggestions: make([]*catalog_service.ProductSuggestion, 0)}, nil
+	}
+
+	if req.Limit <= 0 || req.Limit > 50 {
+		req.Limit = 10
+	}
+
+	return c.elastic.Product().SuggestProducts(req)
+}
+
+func (c *catalogService) GetValuation(ctx context.Context, req *catalog_service.GetValuationRequest) (*catalog_service.GetValuationResponse, error) {
+
+	if req.Request == nil || req.Request.CompanyId == "" {
+		return nil, errors.New("company_id is required")
+	}
+
+	return c.elastic.Product().GetValuation(req)
+}
+
+// BulkUpdateProductJob returns id of the bulk update job
+func (c *catalogService) BulkUpdateProductJob(ctx context.Context, req *catalog_service.ProductBulkOperationRequest) (*common.ResponseID, error) {
+	return c.createJob(req.GetRequest(), config.JobProductBulkUpdate, req)
+}
+
 func (c *catalogService) BulkUpdateProduct(ctx context.Context, req *catalog_service.ProductBulkOperationRequest) (*common.ResponseID, error) {
 
+	res, outboxId, err := c.bulkUpdateProduct(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	reportJobProgress(ctx, 80)
+
+	if err := c.kafka.WaitOutbox(ctx, outboxId); err != nil {
+		return nil, errors.Wrap(err, "error while publishing product bulk updated event")
+	}
+
+	return res, nil
+}
+
+func (c *catalogService) bulkUpdateProduct(ctx context.Context, req *catalog_service.ProductBulkOperationRequest) (*common.ResponseID, int64, error) {
+
 	var (
 		productMap      = make(map[string]*catalog_service.ProductES)
 		measurementUnit catalog_service.ShortMeasurementUnit
@@ -401,7 +570,7 @@ func (c *catalogService) BulkUpdateProduct(ctx context.Context, req *catalog_ser
 	if req.ProductField == "measurement_value" {
 		mu, err := c.strg.MeasurementUnit().GetByID(&common.RequestID{Id: req.Value, Request: req.Request})
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		measurementUnit = catalog_service.ShortMeasurementUnit{
@@ -422,7 +591,7 @@ func (c *catalogService) BulkUpdateProduct(ctx context.Context, req *catalog_ser
 
 		cat, err := c.strg.Category().GetByID(&common.RequestID{Id: req.Value, Request: req.Request})
 		if err != nil {
-			return nil, errors.Wrap(err, "error while getting categories")
+			return nil, 0, errors.Wrap(err, "error while getting categories")
 		}
 
 		categories = append(categories, &catalog_service.ShortCategory{
@@ -445,7 +614,7 @@ func (c *catalogService) BulkUpdateProduct(ctx context.Context, req *catalog_ser
 
 	tr, err := c.strg.WithTransaction()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	defer func() {
@@ -456,26 +625,25 @@ func (c *catalogService) BulkUpdateProduct(ctx context.Context, req *catalog_ser
 		}
 	}()
 
-	err = c.kafka.Push("v1.catalog_service.product.bulk_updated.success", catalog_service.ProductBulkOperationRequest{
+	res, err := tr.Product().ProductBulkEdit(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	outboxId, err := tr.Outbox().Create("v1.catalog_service.product.bulk_updated.success", req.Request.CompanyId, &catalog_service.ProductBulkOperationRequest{
 		ProductIds:   req.ProductIds,
 		ShopIds:      req.ShopIds,
 		ProductField: req.ProductField,
 		Value:        req.Value,
 		Request:      req.Request,
 	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := tr.Product().ProductBulkEdit(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	err = c.elastic.Product().BulkUpdateProduct(req, productMap)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return res, nil
+	return res, outboxId, nil
 }
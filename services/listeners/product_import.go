@@ -0,0 +1,900 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+	"genproto/catalog_service"
+	"genproto/common"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/Invan2/invan_catalog_service/storage"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+const importBatchSize = 500
+
+// import columns are headers of CreateExelTemplate without the currency
+const (
+	importVariationId    = "VARIATION_ID"
+	importName           = "NAME"
+	importSku            = "SKU"
+	importBarcode        = "BARCODE"
+	importQuantity       = "QUANTITY"
+	importSupplyPrice    = "SUPPLY_PRICE"
+	importRetailPrice    = "RETAIL_PRICE"
+	importCategory       = "CATEGORY_NAME"
+	importBrand          = "BRAND_NAME"
+	importUnit           = "MEASUREMENT_UNIT"
+	importSupplier       = "SUPPLIER"
+	importMinPrice       = "MIN_PRICE"
+	importMaxPrice       = "MAX_PRICE"
+	importWholesalePrice = "WHOLESALE_PRICE"
+)
+
+// match modes of existing products, VARIATION_ID is matched in every mode
+const (
+	importMatchVariationId = "variation_id"
+	importMatchSku         = "sku"
+	importMatchBarcode     = "barcode"
+)
+
+// column modes of matched products
+const (
+	importOverwrite = "overwrite"
+	importKeep      = "keep"
+)
+
+var importColumns = []string{
+	importVariationId,
+	importName,
+	importSku,
+	importBarcode,
+	importQuantity,
+	importSupplyPrice,
+	importRetailPrice,
+	importCategory,
+	importBrand,
+	importUnit,
+	importSupplier,
+	importMinPrice,
+	importMaxPrice,
+	importWholesalePrice,
+}
+
+type importRow struct {
+	number int
+	cells  map[string]string
+	errors []*models.ImportRowError
+}
+
+func (r *importRow) value(column string) string {
+	return strings.TrimSpace(r.cells[column])
+}
+
+func (r *importRow) fail(column, message string) {
+	r.errors = append(r.errors, &models.ImportRowError{
+		Row:    r.number,
+		Column: column,
+		Error:  message,
+	})
+}
+
+type importTable struct {
+	// columns maps import column to its index in the file
+	columns map[string]int
+	width   int
+	rows    []*importRow
+	// decimal separator of numbers, detected by each number if empty
+	decimal string
+}
+
+// normalizeImportHeader turns "SUPPLY_PRICE (UZS)" or "Supply price" into SUPPLY_PRICE
+func normalizeImportHeader(header string) string {
+
+	header = strings.ToUpper(strings.TrimSpace(header))
+
+	if i := strings.Index(header, "("); i >= 0 {
+		header = header[:i]
+	}
+
+	return strings.Join(strings.Fields(header), "_")
+}
+
+// newImportTable reads header from the first record, empty records are skipped.
+// mapping maps normalized headers of the file to import columns, headers not in mapping are matched by name
+func newImportTable(records [][]string, mapping map[string]string) (*importTable, error) {
+
+	var (
+		table = importTable{
+			columns: make(map[string]int),
+		}
+		known = make(map[string]bool, len(importColumns))
+	)
+
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	for _, column := range importColumns {
+		known[column] = true
+	}
+
+	table.width = len(records[0])
+
+	for i, header := range records[0] {
+		column := normalizeImportHeader(header)
+		if mapped, ok := mapping[column]; ok {
+			column = mapped
+		}
+
+		if _, ok := table.columns[column]; ok || !known[column] {
+			continue
+		}
+
+		table.columns[column] = i
+	}
+
+	if len(table.columns) == 0 {
+		return nil, errors.New("file has no known columns")
+	}
+
+	for i, record := range records[1:] {
+
+		var (
+			row   = importRow{number: i + 2, cells: make(map[string]string)}
+			empty = true
+		)
+
+		for column, index := range table.columns {
+			if index >= len(record) {
+				continue
+			}
+
+			row.cells[column] = record[index]
+			if strings.TrimSpace(record[index]) != "" {
+				empty = false
+			}
+		}
+
+		if !empty {
+			table.rows = append(table.rows, &row)
+		}
+	}
+
+	return &table, nil
+}
+
+func isImportColumn(column string) bool {
+	for _, importColumn := range importColumns {
+		if importColumn == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateImportRequest checks match mode and column modes, columns are normalized as headers of the file
+func validateImportRequest(req *models.ImportProductsRequest) (map[string]string, error) {
+
+	var columns = make(map[string]string, len(req.Columns))
+
+	switch req.MatchBy {
+	case "", importMatchVariationId, importMatchSku, importMatchBarcode:
+	default:
+		return nil, fmt.Errorf("unknown match_by %q, must be one of %s, %s, %s", req.MatchBy, importMatchVariationId, importMatchSku, importMatchBarcode)
+	}
+
+	for column, mode := range req.Columns {
+		name := normalizeImportHeader(column)
+		if !isImportColumn(name) {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+
+		if mode != importOverwrite && mode != importKeep {
+			return nil, fmt.Errorf("unknown mode %q of column %q, must be %s or %s", mode, column, importOverwrite, importKeep)
+		}
+
+		columns[name] = mode
+	}
+
+	return columns, nil
+}
+
+// splitImportList splits comma separated cell, e.g. barcodes or categories
+func splitImportList(value string) []string {
+
+	var res = make([]string, 0)
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+
+	return res
+}
+
+// parseImportNumber parses the number with the decimal separator, the other one of "." and "," is thousands separator.
+// Without the separator it is detected by the value, a single separator followed by 3 digits like "1,500" is rejected
+func parseImportNumber(value, decimal string) (float32, error) {
+
+	value = strings.NewReplacer(" ", "", "\u00a0", "").Replace(value)
+
+	if decimal == "" {
+		var err error
+		if decimal, err = detectNumberDecimal(value); err != nil {
+			return 0, err
+		}
+	}
+
+	thousands := ","
+	if decimal == "," {
+		thousands = "."
+	}
+
+	value = strings.Replace(strings.ReplaceAll(value, thousands, ""), decimal, ".", 1)
+
+	number, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return 0, errors.New("not a number")
+	}
+
+	if number < 0 {
+		return 0, errors.New("must not be negative")
+	}
+
+	return float32(number), nil
+}
+
+// detectNumberDecimal returns the decimal separator of the value: the last one of both separators,
+// "." for a separator repeated as thousands separator or a single separator not followed by 3 digits
+func detectNumberDecimal(value string) (string, error) {
+
+	dot, comma := strings.LastIndex(value, "."), strings.LastIndex(value, ",")
+
+	switch {
+	case dot >= 0 && comma >= 0:
+		if comma > dot {
+			return ",", nil
+		}
+		return ".", nil
+	case dot < 0 && comma < 0:
+		return ".", nil
+	}
+
+	separator, index, other := ",", comma, "."
+	if dot >= 0 {
+		separator, index, other = ".", dot, ","
+	}
+
+	switch {
+	case strings.Count(value, separator) > 1:
+		return other, nil
+	case len(value)-index-1 == 3:
+		return "", errors.New("decimal separator is ambiguous, write the number without thousands separator")
+	}
+
+	return separator, nil
+}
+
+// importedProduct is a valid row converted to the copy request of InsertMany
+type importedProduct struct {
+	product     *common.CreateProductCopyRequest
+	categoryIds []string
+	isNew       bool
+	// stock is set when QUANTITY of the row is applied
+	stock bool
+	// skipped product is matched but the row does not change it
+	skipped bool
+}
+
+// productImport holds everything the rows are resolved against
+type productImport struct {
+	req        *models.ImportProductsRequest
+	columns    map[string]string
+	decimal    string
+	shopIds    []string
+	categories map[string]string
+	brands     map[string]string
+	suppliers  map[string]string
+	units      map[string]string
+	existing   map[string]*common.CreateProductCopyRequest
+	// current categories of existing products
+	productCategories map[string][]string
+	// product ids by sku or barcode for the match mode
+	matches map[string][]string
+	// first row of the value in the file, to reject duplicates
+	ids      map[string]int
+	skus     map[string]int
+	barcodes map[string]int
+}
+
+func (c *catalogService) newProductImport(req *models.ImportProductsRequest, table *importTable) (*productImport, error) {
+
+	var (
+		companyId = req.Request.GetCompanyId()
+		imp       = productImport{
+			req:      req,
+			decimal:  table.decimal,
+			shopIds:  req.ShopIds,
+			existing: make(map[string]*common.CreateProductCopyRequest),
+			matches:  make(map[string][]string),
+			ids:      make(map[string]int),
+			skus:     make(map[string]int),
+			barcodes: make(map[string]int),
+		}
+		names  = make(map[string][]string)
+		ids    = make([]string, 0)
+		values = make([]string, 0)
+		err    error
+	)
+
+	if imp.columns, err = validateImportRequest(req); err != nil {
+		return nil, err
+	}
+
+	if len(imp.shopIds) == 0 {
+		shops, err := c.strg.Shop().GetAll(&models.GetShopsReq{CompanyId: companyId})
+		if err != nil {
+			return nil, errors.Wrap(err, "error while getting shops")
+		}
+
+		for _, shop := range shops {
+			imp.shopIds = append(imp.shopIds, shop.Id)
+		}
+	}
+
+	for _, row := range table.rows {
+		for _, column := range []string{importBrand, importUnit, importSupplier} {
+			if value := row.value(column); value != "" {
+				names[column] = append(names[column], value)
+			}
+		}
+
+		names[importCategory] = append(names[importCategory], splitImportList(row.value(importCategory))...)
+
+		if _, err := uuid.Parse(row.value(importVariationId)); err == nil {
+			ids = append(ids, row.value(importVariationId))
+			continue
+		}
+
+		switch req.MatchBy {
+		case importMatchSku:
+			if sku := row.value(importSku); sku != "" {
+				values = append(values, sku)
+			}
+		case importMatchBarcode:
+			values = append(values, splitImportList(row.value(importBarcode))...)
+		}
+	}
+
+	switch req.MatchBy {
+	case importMatchSku:
+		imp.matches, err = c.strg.Product().GetIdsBySkus(companyId, values)
+	case importMatchBarcode:
+		imp.matches, err = c.strg.Product().GetIdsByBarcodes(companyId, values)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, matched := range imp.matches {
+		ids = append(ids, matched...)
+	}
+
+	if imp.categories, err = c.strg.Product().GetCategoryIdsByNames(companyId, names[importCategory]); err != nil {
+		return nil, err
+	}
+
+	if imp.brands, err = c.strg.Product().GetBrandIdsByNames(companyId, names[importBrand]); err != nil {
+		return nil, err
+	}
+
+	if imp.suppliers, err = c.strg.Product().GetSupplierIdsByNames(companyId, names[importSupplier]); err != nil {
+		return nil, err
+	}
+
+	if imp.units, err = c.strg.Product().GetMeasurementUnitIdsByNames(companyId, names[importUnit]); err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 {
+		products, err := c.strg.Product().GetCopyRequests(&models.CatalogReplayRequest{CompanyId: companyId, ProductIds: ids}, "", len(ids))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, product := range products {
+			// shop values are limited to the import shops as they are after build, so unchanged products compare equal
+			product.Request = req.Request
+			product.ShopMeasurementValues = imp.shopValues(product.ShopMeasurementValues, nil)
+			imp.existing[product.Id] = product
+		}
+	}
+
+	if imp.productCategories, err = c.strg.Product().GetCategoryIdsByProducts(ids); err != nil {
+		return nil, err
+	}
+
+	return &imp, nil
+}
+
+// lookup resolves the name of the column, the cell is marked bad if the name is unknown
+func (imp *productImport) lookup(row *importRow, column string, ids map[string]string, name string) string {
+
+	id, ok := ids[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		row.fail(column, fmt.Sprintf("%q not found", name))
+	}
+
+	return id
+}
+
+// unique marks the cell bad if the value is already used by another row of the file
+func (imp *productImport) unique(row *importRow, column string, seen map[string]int, value string) {
+
+	if first, ok := seen[value]; ok {
+		row.fail(column, fmt.Sprintf("%q is duplicated in row %d", value, first))
+		return
+	}
+
+	seen[value] = row.number
+}
+
+// match finds the existing product of the row by VARIATION_ID or by the match mode, nil if the row is a new product
+func (imp *productImport) match(row *importRow) *common.CreateProductCopyRequest {
+
+	var column, id string
+
+	if value := row.value(importVariationId); value != "" {
+		if _, err := uuid.Parse(value); err != nil {
+			row.fail(importVariationId, "invalid id")
+			return nil
+		}
+
+		column, id = importVariationId, value
+	} else {
+		var values []string
+
+		switch imp.req.MatchBy {
+		case importMatchSku:
+			column, values = importSku, []string{row.value(importSku)}
+		case importMatchBarcode:
+			column, values = importBarcode, splitImportList(row.value(importBarcode))
+		}
+
+		matched := make(map[string]bool)
+		for _, value := range values {
+			for _, productId := range imp.matches[value] {
+				matched[productId] = true
+				id = productId
+			}
+		}
+
+		if len(matched) > 1 {
+			row.fail(column, fmt.Sprintf("matches %d products", len(matched)))
+			return nil
+		}
+	}
+
+	if id == "" {
+		return nil
+	}
+
+	existing, ok := imp.existing[id]
+	if !ok {
+		row.fail(column, "product not found")
+		return nil
+	}
+
+	if first, ok := imp.ids[id]; ok {
+		row.fail(column, fmt.Sprintf("product is already imported by row %d", first))
+		return nil
+	}
+
+	imp.ids[id] = row.number
+
+	return existing
+}
+
+// apply tells if the cell of the column is set to the product, empty cells and kept columns of matched products are not
+func (imp *productImport) apply(res *importedProduct, row *importRow, column string) bool {
+	return row.value(column) != "" && (res.isNew || imp.columns[column] != importKeep)
+}
+
+// build validates the row and converts it to the product, nil if the row is rejected.
+// Empty cells of existing products keep current values
+func (imp *productImport) build(row *importRow) *importedProduct {
+
+	var (
+		res = importedProduct{
+			product: &common.CreateProductCopyRequest{
+				Id:            uuid.NewString(),
+				ProductTypeId: config.SimpleProductTypeID,
+				Request:       imp.req.Request,
+			},
+			isNew: true,
+		}
+		numbers  = make(map[string]float32)
+		existing = imp.match(row)
+	)
+
+	if existing != nil {
+		res.product = proto.Clone(existing).(*common.CreateProductCopyRequest)
+		res.isNew = false
+	}
+
+	product := res.product
+
+	if imp.apply(&res, row, importName) {
+		product.Name = row.value(importName)
+	} else if res.isNew {
+		row.fail(importName, "name is required")
+	}
+
+	// sku and barcodes of the file must be unique even if they are kept, they may be used for matching
+	if sku := row.value(importSku); sku != "" {
+		imp.unique(row, importSku, imp.skus, sku)
+	}
+
+	if imp.apply(&res, row, importSku) {
+		product.Sku = row.value(importSku)
+	}
+
+	barcodes := splitImportList(row.value(importBarcode))
+	for _, barcode := range barcodes {
+		imp.unique(row, importBarcode, imp.barcodes, barcode)
+	}
+
+	if imp.apply(&res, row, importBarcode) {
+		product.Barcode = barcodes
+	}
+
+	if imp.apply(&res, row, importCategory) {
+		for _, name := range splitImportList(row.value(importCategory)) {
+			if id := imp.lookup(row, importCategory, imp.categories, name); id != "" {
+				res.categoryIds = append(res.categoryIds, id)
+			}
+		}
+	}
+
+	if imp.apply(&res, row, importBrand) {
+		product.BrandId = imp.lookup(row, importBrand, imp.brands, row.value(importBrand))
+	}
+
+	if imp.apply(&res, row, importSupplier) {
+		product.SupplierId = imp.lookup(row, importSupplier, imp.suppliers, row.value(importSupplier))
+	}
+
+	if imp.apply(&res, row, importUnit) {
+		product.MeasurementUnitId = imp.lookup(row, importUnit, imp.units, row.value(importUnit))
+	} else if res.isNew {
+		row.fail(importUnit, "measurement unit is required")
+	}
+
+	for _, column := range append([]string{importQuantity}, importPriceColumns()...) {
+		if !imp.apply(&res, row, column) {
+			continue
+		}
+
+		value := row.value(column)
+
+		number, err := parseImportNumber(value, imp.decimal)
+		if err != nil {
+			row.fail(column, err.Error())
+			continue
+		}
+
+		numbers[column] = number
+	}
+
+	if numbers[importMinPrice] > 0 && numbers[importMaxPrice] > 0 && numbers[importMinPrice] > numbers[importMaxPrice] {
+		row.fail(importMinPrice, "must not be greater than max price")
+	}
+
+	if len(row.errors) > 0 {
+		return nil
+	}
+
+	product.ShopMeasurementValues = imp.shopValues(product.ShopMeasurementValues, numbers)
+	_, res.stock = numbers[importQuantity]
+
+	if existing != nil {
+		res.skipped = proto.Equal(existing, product) && !imp.categoriesChanged(product.Id, res.categoryIds)
+	}
+
+	return &res
+}
+
+// categoriesChanged compares categories of the row with current categories, empty categories keep current ones
+func (imp *productImport) categoriesChanged(productId string, categoryIds []string) bool {
+
+	if len(categoryIds) == 0 {
+		return false
+	}
+
+	current := append([]string{}, imp.productCategories[productId]...)
+	changed := append([]string{}, categoryIds...)
+
+	if len(current) != len(changed) {
+		return true
+	}
+
+	sort.Strings(current)
+	sort.Strings(changed)
+
+	for i := range current {
+		if current[i] != changed[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func importPriceColumns() []string {
+	return []string{importSupplyPrice, importRetailPrice, importMinPrice, importMaxPrice, importWholesalePrice}
+}
+
+// shopValues sets quantity and prices of the import shops, values of other shops are left out to stay untouched
+func (imp *productImport) shopValues(current []*common.CommonShopMeasurementValue, numbers map[string]float32) []*common.CommonShopMeasurementValue {
+
+	var (
+		res    = make([]*common.CommonShopMeasurementValue, 0, len(imp.shopIds))
+		byShop = make(map[string]*common.CommonShopMeasurementValue, len(current))
+	)
+
+	for _, value := range current {
+		byShop[value.ShopId] = value
+	}
+
+	for _, shopId := range imp.shopIds {
+		value, ok := byShop[shopId]
+		if !ok {
+			value = &common.CommonShopMeasurementValue{ShopId: shopId, IsAvailable: true}
+		}
+
+		for column, number := range numbers {
+			switch column {
+			case importQuantity:
+				value.InStock = number
+			case importSupplyPrice:
+				value.SupplyPrice = number
+			case importRetailPrice:
+				value.RetailPrice = number
+			case importMinPrice:
+				value.MinPrice = number
+			case importMaxPrice:
+				value.MaxPrice = number
+			case importWholesalePrice:
+				value.WholeSalePrice = number
+			}
+		}
+
+		res = append(res, value)
+	}
+
+	return res
+}
+
+func importRequestFromProto(req *catalog_service.ImportProductsRequest) *models.ImportProductsRequest {
+	return &models.ImportProductsRequest{
+		Request:  req.Request,
+		FileName: req.FileName,
+		ShopIds:  req.ShopIds,
+		MatchBy:  req.MatchBy,
+		Columns:  req.Columns,
+		DryRun:   req.DryRun,
+	}
+}
+
+func importResponseToProto(res *models.ImportProductsResponse) *catalog_service.ImportProductsResponse {
+
+	out := catalog_service.ImportProductsResponse{
+		Total:        int32(res.Total),
+		Created:      int32(res.Created),
+		Updated:      int32(res.Updated),
+		Skipped:      int32(res.Skipped),
+		Rejected:     int32(res.Rejected),
+		Errors:       make([]*catalog_service.ImportRowError, 0, len(res.Errors)),
+		ErrorFileUrl: res.ErrorFileUrl,
+	}
+
+	for _, rowError := range res.Errors {
+		out.Errors = append(out.Errors, &catalog_service.ImportRowError{
+			Row:    int32(rowError.Row),
+			Column: rowError.Column,
+			Error:  rowError.Error,
+		})
+	}
+
+	return &out
+}
+
+// importProducts saves valid rows of the table all or nothing, rejected rows are returned as errors.
+// Dry run only counts the rows
+func (c *catalogService) importProducts(ctx context.Context, req *models.ImportProductsRequest, table *importTable) (*models.ImportProductsResponse, error) {
+
+	var (
+		res = models.ImportProductsResponse{
+			Total:  len(table.rows),
+			Errors: make([]*models.ImportRowError, 0),
+		}
+		products = make([]*importedProduct, 0, len(table.rows))
+	)
+
+	imp, err := c.newProductImport(req, table)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range table.rows {
+		product := imp.build(row)
+		if product == nil {
+			res.Rejected++
+			res.Errors = append(res.Errors, row.errors...)
+			continue
+		}
+
+		switch {
+		case product.skipped:
+			res.Skipped++
+			continue
+		case product.isNew:
+			res.Created++
+		default:
+			res.Updated++
+		}
+
+		products = append(products, product)
+	}
+
+	if req.DryRun {
+		c.log.Info("products import checked", logger.Any("result", res))
+		return &res, nil
+	}
+
+	if err := c.saveImportedProducts(ctx, req.Request.GetCompanyId(), products); err != nil {
+		return nil, err
+	}
+
+	c.log.Info("products imported", logger.Any("result", res))
+
+	return &res, nil
+}
+
+// saveImportedProducts saves all products in one transaction, so a failed import changes nothing.
+// Products are written in batches to keep the statements small
+func (c *catalogService) saveImportedProducts(ctx context.Context, companyId string, products []*importedProduct) (err error) {
+
+	tr, err := c.strg.WithTransaction()
+	if err != nil {
+		return errors.Wrap(err, "error while run transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tr.Rollback()
+		}
+	}()
+
+	for start := 0; start < len(products); start += importBatchSize {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		end := start + importBatchSize
+		if end > len(products) {
+			end = len(products)
+		}
+
+		if err = saveImportBatch(tr, companyId, products[start:end]); err != nil {
+			return err
+		}
+	}
+
+	if err = tr.Commit(); err != nil {
+		return errors.Wrap(err, "error while commit import")
+	}
+
+	return nil
+}
+
+func saveImportBatch(tr storage.StorageTrI, companyId string, products []*importedProduct) error {
+
+	var (
+		copies     = make([]*common.CreateProductCopyRequest, 0, len(products))
+		ids        = make([]string, 0, len(products))
+		updatedIds = make([]string, 0)
+		keepIds    = make([]string, 0)
+		stockIds   = make([]string, 0)
+		categories = make(map[string][]string)
+	)
+
+	for _, product := range products {
+		copies = append(copies, product.product)
+		ids = append(ids, product.product.Id)
+
+		if len(product.categoryIds) > 0 {
+			categories[product.product.Id] = product.categoryIds
+		}
+
+		if product.stock {
+			stockIds = append(stockIds, product.product.Id)
+		}
+
+		if !product.isNew {
+			updatedIds = append(updatedIds, product.product.Id)
+
+			if len(product.categoryIds) == 0 {
+				keepIds = append(keepIds, product.product.Id)
+			}
+		}
+	}
+
+	if err := tr.Product().ImportMany(copies, stockIds); err != nil {
+		return err
+	}
+
+	if len(stockIds) > 0 {
+		if _, err := tr.StockMovement().Register(uuid.NewString(), config.StockMovementImport); err != nil {
+			return err
+		}
+	}
+
+	if err := tr.Product().SetCategories(categories); err != nil {
+		return err
+	}
+
+	if err := tr.Product().CopyPreviousCategories(keepIds); err != nil {
+		return err
+	}
+
+	if err := tr.Product().CopyPreviousImages(updatedIds); err != nil {
+		return err
+	}
+
+	_, err := tr.Outbox().Create("v1.inventory_service.create_multiple_products_on_order_service", companyId, &common.CreateImportProductsModel{Products: copies})
+	if err != nil {
+		return err
+	}
+
+	// documents are built from postgres by the consumer, so indexing is retried until it succeeds
+	_, err = tr.Outbox().Create("v1.catalog_service.products.index", companyId, &common.RequestIDs{
+		Ids:     ids,
+		Request: &common.Request{CompanyId: companyId},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// readImportFile reads the uploaded file, fileName may be the url returned by the upload
+func (c *catalogService) readImportFile(ctx context.Context, fileName string) ([]byte, error) {
+
+	object, err := c.minio.GetObject(ctx, config.FileBucketName, path.Base(fileName), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get file from minio")
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while read file from minio")
+	}
+
+	return data, nil
+}
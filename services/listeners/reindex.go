@@ -0,0 +1,229 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+const reindexBatchSize = 500
+
+// ReindexProducts rebuilds products on elastic from postgres.
+// Without company a new versioned index is built and the products alias is switched to it.
+// Products changed or deleted while building are caught up, the last catch up and the switch
+// are done with product writers blocked, so that no change is left on the old index.
+func (c *catalogService) ReindexProducts(ctx context.Context, req *models.ReindexProductsRequest) (res *models.ReindexProductsResponse, err error) {
+
+	if req.CompanyId != "" {
+		return c.reindexCompanyProducts(ctx, req)
+	}
+
+	res = &models.ReindexProductsResponse{
+		Index: fmt.Sprintf("%s_%s", config.ElasticProductIndex, time.Now().Format(config.DateTimeFormatWithoutSpaces)),
+	}
+
+	startedAt, err := c.strg.Product().IndexCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.elastic.ProductIndex().CreateIndex(res.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	swapped := false
+	defer func() {
+		if swapped {
+			return
+		}
+
+		if err := c.elastic.ProductIndex().DeleteIndex(res.Index); err != nil {
+			c.log.Error("error while delete unused products index", logger.String("index", res.Index), logger.Error(err))
+		}
+	}()
+
+	res.Indexed, err = c.indexProducts(ctx, res.Index, &models.ProductIndexFilter{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	builtAt, err := c.strg.Product().IndexCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.catchUpProducts(ctx, res, startedAt); err != nil {
+		return nil, err
+	}
+
+	tr, err := c.strg.WithTransaction()
+	if err != nil {
+		return nil, errors.Wrap(err, "error while run transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tr.Rollback()
+		} else {
+			_ = tr.Commit()
+		}
+	}()
+
+	if err = tr.Product().LockForIndex(); err != nil {
+		return nil, err
+	}
+
+	if err = c.catchUpProducts(ctx, res, builtAt); err != nil {
+		return nil, err
+	}
+
+	if err = c.verifyProductsCount(res.Index, &models.ProductIndexFilter{}); err != nil {
+		return nil, err
+	}
+
+	res.OldIndex, err = c.elastic.ProductIndex().SwapAlias(config.ElasticProductIndex, res.Index, req.KeepOldIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	swapped = true
+
+	if req.KeepOldIndex {
+		return res, nil
+	}
+
+	for _, index := range res.OldIndex {
+		if err := c.elastic.ProductIndex().DeleteIndex(index); err != nil {
+			c.log.Error("error while delete old products index", logger.String("index", index), logger.Error(err))
+		}
+	}
+
+	return res, nil
+}
+
+// catchUpProducts indexes products updated and deletes products deleted since the checkpoint
+func (c *catalogService) catchUpProducts(ctx context.Context, res *models.ReindexProductsResponse, since string) error {
+
+	filter := models.ProductIndexFilter{UpdatedSince: since}
+
+	if _, err := c.indexProducts(ctx, res.Index, &filter, nil); err != nil {
+		return err
+	}
+
+	deleted, err := c.strg.Product().GetDeletedIdsForIndex(&filter)
+	if err != nil {
+		return err
+	}
+
+	if err := c.elastic.ProductIndex().DeleteIds(res.Index, deleted); err != nil {
+		return err
+	}
+
+	res.Deleted += len(deleted)
+
+	return nil
+}
+
+func (c *catalogService) reindexCompanyProducts(ctx context.Context, req *models.ReindexProductsRequest) (*models.ReindexProductsResponse, error) {
+
+	var (
+		filter = models.ProductIndexFilter{CompanyId: req.CompanyId}
+		seen   = make(map[string]bool)
+		stale  = make([]string, 0)
+		res    = models.ReindexProductsResponse{Index: config.ElasticProductIndex}
+	)
+
+	indexed, err := c.elastic.ProductIndex().GetIds(config.ElasticProductIndex, req.CompanyId)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Indexed, err = c.indexProducts(ctx, config.ElasticProductIndex, &filter, seen)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range indexed {
+		if !seen[id] {
+			stale = append(stale, id)
+		}
+	}
+
+	if err := c.elastic.ProductIndex().DeleteIds(config.ElasticProductIndex, stale); err != nil {
+		return nil, err
+	}
+
+	res.Deleted = len(stale)
+
+	if err := c.verifyProductsCount(config.ElasticProductIndex, &filter); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *catalogService) indexProducts(ctx context.Context, index string, filter *models.ProductIndexFilter, seen map[string]bool) (int, error) {
+
+	var (
+		afterId string
+		indexed int
+	)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		products, err := c.strg.Product().GetForIndex(filter, afterId, reindexBatchSize)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := c.elastic.ProductIndex().BulkIndex(index, products); err != nil {
+			return 0, err
+		}
+
+		for _, product := range products {
+			if seen != nil {
+				seen[product.Id] = true
+			}
+		}
+
+		indexed += len(products)
+
+		c.log.Info("products indexed", logger.String("index", index), logger.String("company_id", filter.CompanyId), logger.Int("count", indexed))
+
+		if len(products) < reindexBatchSize {
+			break
+		}
+
+		afterId = products[len(products)-1].Id
+	}
+
+	return indexed, nil
+}
+
+func (c *catalogService) verifyProductsCount(index string, filter *models.ProductIndexFilter) error {
+
+	expected, err := c.strg.Product().CountForIndex(filter)
+	if err != nil {
+		return err
+	}
+
+	actual, err := c.elastic.ProductIndex().Count(index, filter.CompanyId)
+	if err != nil {
+		return err
+	}
+
+	if expected != actual {
+		return errors.Errorf("products count mismatch on %s: postgres %d, elastic %d", index, expected, actual)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+	"genproto/catalog_service"
+	"genproto/common"
+	"time"
+
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	replayBatchSize     = 100
+	replayMaxRate       = 10000
+	replayProgressTopic = "v1.catalog_service.replay.progress"
+)
+
+// ReplayCatalog re-publishes product and measurement unit copies of the company for downstream services
+func (c *catalogService) ReplayCatalog(ctx context.Context, req *catalog_service.ReplayCatalogRequest) (*catalog_service.ReplayCatalogResponse, error) {
+
+	var (
+		res     catalog_service.ReplayCatalogResponse
+		afterId string
+		rate    = int(req.RatePerSecond)
+	)
+
+	if req.Request == nil || req.Request.CompanyId == "" {
+		return nil, errors.New("company_id is required")
+	}
+
+	if rate <= 0 {
+		rate = c.cfg.ReplayRatePerSecond
+	}
+
+	if rate > replayMaxRate {
+		return nil, fmt.Errorf("rate_per_second must not be greater than %d", replayMaxRate)
+	}
+
+	filter := &models.CatalogReplayRequest{
+		CompanyId:    req.Request.CompanyId,
+		ShopId:       req.ShopId,
+		UpdatedSince: req.UpdatedSince,
+		ProductIds:   req.ProductIds,
+	}
+
+	units, err := c.replayMeasurementUnits(req.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	res.MeasurementUnits = units
+
+	total, err := c.strg.Product().CountForReplay(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	throttle := time.NewTicker(time.Second / time.Duration(rate))
+	defer throttle.Stop()
+
+	for {
+		products, err := c.strg.Product().GetCopyRequests(filter, afterId, replayBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, product := range products {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-throttle.C:
+			}
+
+			if err := c.kafka.Push("v1.catalog_service.product.created.success", product.Id, product); err != nil {
+				return nil, errors.Wrap(err, "error while replay product")
+			}
+
+			res.Products++
+		}
+
+		if total > 0 {
+			c.reportReplayProgress(filter.CompanyId, req.CorrelationId, float64(res.Products)*100/float64(total))
+		}
+
+		if len(products) < replayBatchSize {
+			break
+		}
+
+		afterId = products[len(products)-1].Id
+	}
+
+	c.reportReplayProgress(filter.CompanyId, req.CorrelationId, 100)
+
+	return &res, nil
+}
+
+// replayMeasurementUnits publishes measurement units of the company page by page
+func (c *catalogService) replayMeasurementUnits(userReq *common.Request) (int32, error) {
+
+	var count int32
+
+	for page := int32(1); ; page++ {
+		measurementUnits, err := c.strg.MeasurementUnit().GetAll(&catalog_service.GetAllMeasurementUnitsRequest{Limit: replayBatchSize, Page: page, Request: userReq})
+		if err != nil {
+			return 0, err
+		}
+
+		if len(measurementUnits.Data) == 0 {
+			break
+		}
+
+		units := make([]*common.MeasurementUnitCopyRequest, 0, len(measurementUnits.Data))
+
+		for _, unit := range measurementUnits.Data {
+			copyReq := &common.MeasurementUnitCopyRequest{
+				Id:                   unit.Id,
+				CompanyId:            userReq.CompanyId,
+				IsDeletable:          unit.IsDeletable,
+				ShortName:            unit.ShortName,
+				LongName:             unit.LongName,
+				Request:              userReq,
+				LongNameTranslation:  unit.LongNameTranslation,
+				ShortNameTranslation: unit.ShortNameTranslation,
+			}
+
+			if unit.Precision != nil {
+				copyReq.Precision = unit.Precision.Value
+			}
+
+			units = append(units, copyReq)
+		}
+
+		err = c.kafka.Push("v1.catalog_service.measurement_units.created.success", userReq.CompanyId, &common.MeasurementUnitsCopyRequest{
+			MeasurementUnits: units,
+			Request:          userReq,
+		})
+		if err != nil {
+			return 0, errors.Wrap(err, "error while replay measurement units")
+		}
+
+		count += int32(len(units))
+
+		if len(measurementUnits.Data) < replayBatchSize {
+			break
+		}
+	}
+
+	return count, nil
+}
+
+func (c *catalogService) reportReplayProgress(companyId, correlationId string, percentage float64) {
+
+	c.log.Info("catalog replay", logger.String("company_id", companyId), logger.Any("percentage", percentage))
+
+	if correlationId == "" {
+		return
+	}
+
+	err := c.kafka.Push(replayProgressTopic, companyId, &models.ProgressResponse{
+		CorrelationID: correlationId,
+		Percentage:    percentage,
+	})
+	if err != nil {
+		c.log.Error("error while push replay progress", logger.Error(err))
+	}
+}
@@ -36,35 +36,39 @@ func (c *catalogService) GetScalesTemplateByID(ctx context.Context, req *catalog
 		return nil, err
 	}
 
-	// Get products
-	products, err := c.elastic.Product().GetAll(&catalog_service.GetAllProductsRequest{
-		Limit:          10000,
-		Page:           1,
+	var (
+		line  string
+		text  = ``
+		count int
+	)
+
+	err = c.elastic.Product().ScanAll(ctx, &catalog_service.GetAllProductsRequest{
 		Request:        req.GetRequest(),
 		ShopIds:        []string{req.GetShopId()},
 		MeasurementIds: res.GetMeasurementUnitId(),
+	}, func(products []*catalog_service.ProductES, total int64) error {
+		for _, v := range products {
+			line = strings.ReplaceAll(res.GetValues(), "{sku}", v.GetSku())
+			line = strings.ReplaceAll(line, "{name}", v.GetName())
+			if res.GetName() == "Mettler toledo Spct 1" {
+				line = strings.ReplaceAll(line, "{price}", strconv.Itoa(int(v.GetShopPrices()[req.GetShopId()].GetRetailPrice())/100))
+			} else {
+				line = strings.ReplaceAll(line, "{price}", strconv.Itoa(int(v.GetShopPrices()[req.GetShopId()].GetRetailPrice())))
+			}
+			text += line + `
+`
+		}
+
+		count += len(products)
+		reportJobProgress(ctx, 90*count/int(total))
+
+		return nil
 	})
 	if err != nil {
-		c.log.Error("c.elastic.Product().GetAll for GetScalesTemplateByID", logger.Any("request", err))
+		c.log.Error("c.elastic.Product().ScanAll for GetScalesTemplateByID", logger.Any("request", err))
 		return nil, err
 	}
-	fmt.Println("len of products --- ", len(products.GetData()))
-
-	var (
-		line string
-		text = ``
-	)
-	for _, v := range products.GetData() {
-		line = strings.ReplaceAll(res.GetValues(), "{sku}", v.GetSku())
-		line = strings.ReplaceAll(line, "{name}", v.GetName())
-		if res.GetName() == "Mettler toledo Spct 1" {
-			line = strings.ReplaceAll(line, "{price}", strconv.Itoa(int(v.GetShopPrices()[req.GetShopId()].GetRetailPrice())/100))
-		} else {
-			line = strings.ReplaceAll(line, "{price}", strconv.Itoa(int(v.GetShopPrices()[req.GetShopId()].GetRetailPrice())))
-		}
-		text += line + `
-`
-	}
+	c.log.Debug("scales template products", logger.Int("count", count))
 
 	res.Url, err = c.UploadTemplateToMinio(text)
 	if err != nil {
@@ -73,6 +77,12 @@ func (c *catalogService) GetScalesTemplateByID(ctx context.Context, req *catalog
 
 	return
 }
+
+// GenerateScalesFile generates the file of GetScalesTemplateByID as a job
+func (c *catalogService) GenerateScalesFile(ctx context.Context, req *catalog_service.GetScalesTemplateByIDRequest) (*common.ResponseID, error) {
+	return c.createJob(req.GetRequest(), config.JobScalesFile, req)
+}
+
 func (c *catalogService) GetAllScalesTemplates(ctx context.Context, req *catalog_service.GetAllScalesTemplatesRequest) (res *catalog_service.GetAllScalesTemplatesResponse, err error) {
 
 	c.log.Info("GetAllScalesTemplates", logger.Any("request", req))
@@ -12,10 +12,12 @@ type storageES struct {
 	db          *elasticsearch.Client
 	log         logger.Logger
 	productRepo repo.ProductESI
+	indexRepo   repo.ProductIndexESI
 }
 
 type StorageES interface {
 	Product() repo.ProductESI
+	ProductIndex() repo.ProductIndexESI
 }
 
 func NewStorageES(log logger.Logger, db *elasticsearch.Client, cfg config.Config) StorageES {
@@ -23,9 +25,14 @@ func NewStorageES(log logger.Logger, db *elasticsearch.Client, cfg config.Config
 		db:          db,
 		log:         log,
 		productRepo: elastic.NewProductRepo(log, db, cfg),
+		indexRepo:   elastic.NewProductIndexRepo(log, db),
 	}
 }
 
 func (s *storageES) Product() repo.ProductESI {
 	return s.productRepo
 }
+
+func (s *storageES) ProductIndex() repo.ProductIndexESI {
+	return s.indexRepo
+}
@@ -68,10 +68,11 @@ func (p *productRepo) BulkUpdateProduct(req *catalog_service.ProductBulkOperatio
 					for (shop in params.shop_ids) {
 						 ctx._source.measurement_values[shop].small_left = small_left;
 					}
+					%s
 					return;
 				}
 
-			`, req.ProductField, smallLeft), // in this case req.Value is small_left
+			`, req.ProductField, smallLeft, syncShopsScript), // in this case req.Value is small_left
 			"lang": "painless",
 			"params": H{
 				"products": productMap,
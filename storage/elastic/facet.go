@@ -0,0 +1,219 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"genproto/catalog_service"
+	"io"
+	"sort"
+
+	"github.com/clarketm/json"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+const facetBucketsSize = 100
+
+// facetField name is empty when a product may have several values of the facet
+type facetField struct {
+	id   string
+	name string
+}
+
+var (
+	ErrFacetNotFound = errors.New("facet not found")
+
+	// facetFieldMap keys match filter keys, so a facet ignores its own filter
+	facetFieldMap = map[string]facetField{
+		"category":         {id: "categories.id.keyword"},
+		"supplier":         {id: "supplier.id.keyword", name: "supplier.name.keyword"},
+		"measurement_unit": {id: "measurement_unit.id.keyword", name: "measurement_unit.short_name.keyword"},
+		"vat":              {id: "vat.id.keyword", name: "vat.name.keyword"},
+		"brand":            {id: "brand.id.keyword", name: "brand.name.keyword"},
+		"tags":             {id: "tags.id.keyword"},
+	}
+)
+
+// makeFacetsRequest counts every facet against the request filters except its own filters
+func makeFacetsRequest(products *catalog_service.GetAllProductsRequest) (H, error) {
+
+	var (
+		must = make([]H, 0)
+		aggs = make(H)
+	)
+
+	must = append(must, H{
+		"term": H{
+			"company_id.keyword": products.GetRequest().GetCompanyId(),
+		},
+	})
+
+	if len(products.ProductIds) > 0 {
+		must = append(must, H{
+			"terms": H{
+				"id.keyword": products.GetProductIds(),
+			},
+		})
+	}
+
+	if products.Search != "" {
+		must = append(must, searchQuery(products.GetSearch()))
+	}
+
+	clauses, err := makeFilterClauses(products)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, facet := range products.Facets {
+
+		field, ok := facetFieldMap[facet]
+		if !ok {
+			return nil, ErrFacetNotFound
+		}
+
+		var (
+			facetMust    = make([]H, 0)
+			facetMustNot = make([]H, 0)
+		)
+
+		for _, clause := range clauses {
+			if clause.key == facet {
+				continue
+			}
+
+			if clause.negative {
+				facetMustNot = append(facetMustNot, clause.query)
+			} else {
+				facetMust = append(facetMust, clause.query)
+			}
+		}
+
+		terms := H{
+			"terms": H{
+				"field": field.id,
+				"size":  facetBucketsSize,
+			},
+		}
+
+		if field.name != "" {
+			terms["aggs"] = H{
+				"name": H{
+					"terms": H{
+						"field": field.name,
+						"size":  1,
+					},
+				},
+			}
+		}
+
+		aggs[facet] = H{
+			"filter": H{
+				"bool": H{
+					"must":     facetMust,
+					"must_not": facetMustNot,
+				},
+			},
+			"aggs": H{
+				"values": terms,
+			},
+		}
+	}
+
+	return H{
+		"size": 0,
+		"query": H{
+			"bool": H{
+				"must": must,
+			},
+		},
+		"aggs": aggs,
+	}, nil
+}
+
+// getFacets counts facets of the request, names of category and tags buckets are left empty
+func (p *productRepo) getFacets(req *catalog_service.GetAllProductsRequest) ([]*catalog_service.Facet, error) {
+
+	var (
+		buf bytes.Buffer
+		res = make([]*catalog_service.Facet, 0)
+		r   struct {
+			Aggregations map[string]struct {
+				Values struct {
+					Buckets []struct {
+						Key      string `json:"key"`
+						DocCount int64  `json:"doc_count"`
+						Name     struct {
+							Buckets []struct {
+								Key string `json:"key"`
+							} `json:"buckets"`
+						} `json:"name"`
+					} `json:"buckets"`
+				} `json:"values"`
+			} `json:"aggregations"`
+		}
+	)
+
+	searchReq, err := makeFacetsRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.NewEncoder(&buf).Encode(searchReq); err != nil {
+		return nil, errors.Wrap(err, "error while encode")
+	}
+
+	response, err := p.db.Search(
+		p.db.Search.WithContext(context.Background()),
+		p.db.Search.WithIndex(config.ElasticProductIndex),
+		p.db.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get facets on elastic")
+	}
+	defer response.Body.Close()
+
+	if response.IsError() {
+		data, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		p.log.Error("errror while get product facets ", logger.Any("res", string(data)))
+		return nil, errors.New("error while get product facets on elastic " + string(data))
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&r); err != nil {
+		return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
+	}
+
+	for name, aggregation := range r.Aggregations {
+		facet := &catalog_service.Facet{
+			Name:    name,
+			Buckets: make([]*catalog_service.FacetBucket, 0, len(aggregation.Values.Buckets)),
+		}
+
+		for _, bucket := range aggregation.Values.Buckets {
+			facetBucket := &catalog_service.FacetBucket{
+				Id:    bucket.Key,
+				Count: bucket.DocCount,
+			}
+
+			if len(bucket.Name.Buckets) > 0 {
+				facetBucket.Name = bucket.Name.Buckets[0].Key
+			}
+
+			facet.Buckets = append(facet.Buckets, facetBucket)
+		}
+
+		res = append(res, facet)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res, nil
+}
@@ -0,0 +1,105 @@
+package elastic
+
+import (
+	"encoding/json"
+	"errors"
+	"genproto/catalog_service"
+	"genproto/common"
+	"testing"
+)
+
+func TestMakeFacetsRequest(t *testing.T) {
+
+	request := &common.Request{CompanyId: "c1"}
+
+	tests := []struct {
+		name string
+		req  *catalog_service.GetAllProductsRequest
+		want map[string]string
+		err  error
+	}{
+		{
+			name: "no facets",
+			req:  &catalog_service.GetAllProductsRequest{Request: request},
+			want: map[string]string{},
+		},
+		{
+			name: "brand is named by elastic",
+			req: &catalog_service.GetAllProductsRequest{
+				Request: request,
+				Facets:  []string{"brand"},
+			},
+			want: map[string]string{
+				"brand": `{"aggs":{"values":{"aggs":{"name":{"terms":{"field":"brand.name.keyword","size":1}}},"terms":{"field":"brand.id.keyword","size":100}}},"filter":{"bool":{"must":[],"must_not":[]}}}`,
+			},
+		},
+		{
+			name: "tags ignore own filter",
+			req: &catalog_service.GetAllProductsRequest{
+				Request: request,
+				Facets:  []string{"tags", "supplier"},
+				Filters: []*common.FilterField{{Key: "tags", Relation: common.Relation_EQUAL, Value: "t1"}},
+			},
+			want: map[string]string{
+				"tags":     `{"aggs":{"values":{"terms":{"field":"tags.id.keyword","size":100}}},"filter":{"bool":{"must":[],"must_not":[]}}}`,
+				"supplier": `{"aggs":{"values":{"aggs":{"name":{"terms":{"field":"supplier.name.keyword","size":1}}},"terms":{"field":"supplier.id.keyword","size":100}}},"filter":{"bool":{"must":[{"terms":{"tags.id.keyword":["t1"]}}],"must_not":[]}}}`,
+			},
+		},
+		{
+			name: "negative filter of other facet",
+			req: &catalog_service.GetAllProductsRequest{
+				Request:     request,
+				Facets:      []string{"category"},
+				CategoryIds: []string{"c1"},
+				Filters:     []*common.FilterField{{Key: "brand", Relation: common.Relation_NOT_INCLUDE, Value: "b1"}},
+			},
+			want: map[string]string{
+				"category": `{"aggs":{"values":{"terms":{"field":"categories.id.keyword","size":100}}},"filter":{"bool":{"must":[],"must_not":[{"terms":{"brand.id.keyword":["b1"]}}]}}}`,
+			},
+		},
+		{
+			name: "unknown facet",
+			req: &catalog_service.GetAllProductsRequest{
+				Request: request,
+				Facets:  []string{"color"},
+			},
+			err: ErrFacetNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := makeFacetsRequest(tt.req)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("makeFacetsRequest() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("makeFacetsRequest() error = %v", err)
+			}
+
+			if got["size"] != 0 {
+				t.Errorf("makeFacetsRequest() size = %v, want 0", got["size"])
+			}
+
+			aggs := got["aggs"].(H)
+			if len(aggs) != len(tt.want) {
+				t.Fatalf("makeFacetsRequest() returned %d aggregations, want %d", len(aggs), len(tt.want))
+			}
+
+			for name, want := range tt.want {
+				agg, err := json.Marshal(aggs[name])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(agg) != want {
+					t.Errorf("aggregation %s = %s, want %s", name, agg, want)
+				}
+			}
+		})
+	}
+}
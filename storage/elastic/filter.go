@@ -1,35 +1,161 @@
 package elastic
 
 import (
-	"errors"
+	"genproto/catalog_service"
 	"genproto/common"
+	"strconv"
 	"strings"
+
+	"github.com/pkg/errors"
 )
 
 type filterFunction func(filter *common.FilterField) (H, error)
 
+const (
+	filterDateFormat         = "yyyy-MM-dd HH:mm:ss||yyyy-MM-dd"
+	shopValueFilterSeparator = ":"
+)
+
 var (
-	ErrFilterNotFound = errors.New("filter not found")
+	ErrFilterNotFound       = errors.New("filter not found")
+	ErrRelationNotSupported = errors.New("relation is not supported by filter")
+	ErrInvalidFilterValue   = errors.New("invalid filter value")
+
+	termsRelations = []common.Relation{common.Relation_EQUAL, common.Relation_NOT_EQUAL, common.Relation_INCLUDE, common.Relation_NOT_INCLUDE}
+	boolRelations  = []common.Relation{common.Relation_EQUAL, common.Relation_NOT_EQUAL}
+	rangeRelations = []common.Relation{common.Relation_EQUAL, common.Relation_NOT_EQUAL, common.Relation_GREATER_THAN, common.Relation_LESS_THAN}
 
 	filterFunctionMap = map[string]filterFunction{
-		"category":         category,
-		"measurement_unit": measurementUnit,
-		"product_ids":      productIds,
+		"category":         withRelations(category, termsRelations...),
+		"measurement_unit": withRelations(termsFilter("measurement_unit.id.keyword"), termsRelations...),
+		"product_ids":      withRelations(termsFilter("id.keyword"), termsRelations...),
+		"supplier":         withRelations(termsFilter("supplier.id.keyword"), termsRelations...),
+		"vat":              withRelations(termsFilter("vat.id.keyword"), termsRelations...),
+		"brand":            withRelations(termsFilter("brand.id.keyword"), termsRelations...),
+		"tags":             withRelations(termsFilter("tags.id.keyword"), termsRelations...),
+		"product_type":     withRelations(termsFilter("product_type_id.keyword"), termsRelations...),
+		"parent":           withRelations(termsFilter("parent_id.keyword"), termsRelations...),
+		"is_marking":       withRelations(boolFilter("is_marking"), boolRelations...),
+		"is_variant":       withRelations(isVariant, boolRelations...),
+		"created_at":       withRelations(createdAt, rangeRelations...),
+		"retail_price":     withRelations(shopValueFilter("retail_price"), rangeRelations...),
+		"supply_price":     withRelations(shopValueFilter("supply_price"), rangeRelations...),
+		"amount":           withRelations(shopValueFilter("amount"), rangeRelations...),
+		"out_of_stock":     withRelations(outOfStock, boolRelations...),
+		"low_stock":        withRelations(lowStock, boolRelations...),
 	}
 )
 
+// filterClause is a query of a filter, key is the filter key or facet it narrows
+type filterClause struct {
+	key      string
+	query    H
+	negative bool
+}
+
+// makeFilterClauses returns queries of category, measurement unit and request filters
+func makeFilterClauses(req *catalog_service.GetAllProductsRequest) ([]*filterClause, error) {
+
+	clauses := make([]*filterClause, 0)
+
+	if len(req.CategoryIds) > 0 {
+		clauses = append(clauses, &filterClause{
+			key: "category",
+			query: H{
+				"terms": H{
+					"categories.id.keyword": req.GetCategoryIds(),
+				},
+			},
+		})
+	}
+
+	if len(req.MeasurementIds) > 0 {
+		clauses = append(clauses, &filterClause{
+			key: "measurement_unit",
+			query: H{
+				"terms": H{
+					"measurement_unit.id.keyword": req.GetMeasurementIds(),
+				},
+			},
+		})
+	}
+
+	for _, field := range req.Filters {
+
+		filterFunction, ok := filterFunctionMap[field.Key]
+		if !ok {
+			return nil, ErrFilterNotFound
+		}
+
+		query, err := filterFunction(field)
+		if err != nil {
+			return nil, errors.Wrap(err, "error while filterFunction")
+		}
+
+		clauses = append(clauses, &filterClause{
+			key:      field.Key,
+			query:    query,
+			negative: isNegative(field.Relation),
+		})
+	}
+
+	return clauses, nil
+}
+
+// isNegative reports whether query of the filter goes to must_not
+func isNegative(relation common.Relation) bool {
+	return relation == common.Relation_NOT_EQUAL || relation == common.Relation_NOT_INCLUDE
+}
+
+func withRelations(fn filterFunction, relations ...common.Relation) filterFunction {
+	return func(filter *common.FilterField) (H, error) {
+		for _, relation := range relations {
+			if relation == filter.Relation {
+				return fn(filter)
+			}
+		}
+
+		return nil, ErrRelationNotSupported
+	}
+}
+
+func termsFilter(field string) filterFunction {
+	return func(filter *common.FilterField) (H, error) {
+		return H{
+			"terms": H{
+				field: strings.Split(filter.Value, ","),
+			},
+		}, nil
+	}
+}
+
+func boolFilter(field string) filterFunction {
+	return func(filter *common.FilterField) (H, error) {
+		value, err := strconv.ParseBool(filter.Value)
+		if err != nil {
+			return nil, ErrInvalidFilterValue
+		}
+
+		return H{
+			"term": H{
+				field: value,
+			},
+		}, nil
+	}
+}
+
 func category(filter *common.FilterField) (H, error) {
 	return H{
 		"bool": H{
 			"should": []H{
 				{
 					"terms": H{
-						"categories.parent_id": strings.Split(filter.Value, ","),
+						"categories.parent_id.keyword": strings.Split(filter.Value, ","),
 					},
 				},
 				{
 					"terms": H{
-						"categories.id": strings.Split(filter.Value, ","),
+						"categories.id.keyword": strings.Split(filter.Value, ","),
 					},
 				},
 			},
@@ -37,18 +163,178 @@ func category(filter *common.FilterField) (H, error) {
 	}, nil
 }
 
-func measurementUnit(filter *common.FilterField) (H, error) {
+// isVariant "true" matches products which have a parent
+func isVariant(filter *common.FilterField) (H, error) {
+	value, err := strconv.ParseBool(filter.Value)
+	if err != nil {
+		return nil, ErrInvalidFilterValue
+	}
+
+	hasParent := H{
+		"bool": H{
+			"must_not": []H{
+				{"term": H{"parent_id.keyword": ""}},
+			},
+		},
+	}
+
+	if value {
+		return hasParent, nil
+	}
+
+	return H{"term": H{"parent_id.keyword": ""}}, nil
+}
+
+// createdAt value is a date or date time in config.DateTimeFormat
+func createdAt(filter *common.FilterField) (H, error) {
+
+	condition := H{
+		"format": filterDateFormat,
+	}
+
+	switch filter.Relation {
+	case common.Relation_GREATER_THAN:
+		condition["gt"] = filter.Value
+	case common.Relation_LESS_THAN:
+		condition["lt"] = filter.Value
+	default:
+		condition["gte"] = filter.Value
+		condition["lte"] = filter.Value
+	}
+
 	return H{
-		"terms": H{
-			"measurement_unit.id.keyword": strings.Split(filter.Value, ","),
+		"range": H{
+			"created_at": condition,
 		},
 	}, nil
 }
 
-func productIds(filter *common.FilterField) (H, error) {
+func parseShopValue(value string) (string, float64, error) {
+	parts := strings.SplitN(value, shopValueFilterSeparator, 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", 0, ErrInvalidFilterValue
+	}
+
+	number, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return "", 0, ErrInvalidFilterValue
+	}
+
+	return parts[0], number, nil
+}
+
+// shopsQuery matches products having a nested shop entry of the shops matching the query, any shop if shopIds is empty
+func shopsQuery(shopIds []string, query ...H) H {
+
+	filter := make([]H, 0, len(query)+1)
+	if len(shopIds) > 0 {
+		filter = append(filter, H{"terms": H{"shops.shop_id": shopIds}})
+	}
+
 	return H{
-		"terms": H{
-			"id.keyword": strings.Split(filter.Value, ","),
+		"nested": H{
+			"path":            "shops",
+			"ignore_unmapped": true,
+			"query": H{
+				"bool": H{
+					"filter": append(filter, query...),
+				},
+			},
 		},
-	}, nil
+	}
+}
+
+// shopValueFilter value is "<shop_id>:<number>"
+func shopValueFilter(field string) filterFunction {
+	return func(filter *common.FilterField) (H, error) {
+
+		shopId, number, err := parseShopValue(filter.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		condition := H{}
+
+		switch filter.Relation {
+		case common.Relation_GREATER_THAN:
+			condition["gt"] = number
+		case common.Relation_LESS_THAN:
+			condition["lt"] = number
+		default:
+			condition["gte"] = number
+			condition["lte"] = number
+		}
+
+		return shopsQuery([]string{shopId}, H{"range": H{"shops." + field: condition}}), nil
+	}
+}
+
+// outOfStock "true" matches products having no stock in any of the shops, "false" having stock in some of them
+func outOfStock(filter *common.FilterField) (H, error) {
+
+	shopIds, value, err := parseStockValue(filter.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return outOfStockQuery(shopIds, value), nil
+}
+
+// lowStock matches products which amount is below the low stock threshold in any of the shops
+func lowStock(filter *common.FilterField) (H, error) {
+
+	shopIds, value, err := parseStockValue(filter.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return lowStockQuery(shopIds, value), nil
+}
+
+func outOfStockQuery(shopIds []string, value bool) H {
+	return stockQuery(!value, shopsQuery(shopIds, H{"range": H{"shops.amount": H{"gt": 0}}}))
+}
+
+func lowStockQuery(shopIds []string, value bool) H {
+	return stockQuery(value, shopsQuery(shopIds, lowStockScript()))
+}
+
+// lowStockScript matches nested shop entries which amount is below the low stock threshold
+func lowStockScript() H {
+	return H{
+		"script": H{
+			"script": H{
+				"lang":   "painless",
+				"source": "doc['shops.amount'].value < doc['shops.low_stock'].value",
+			},
+		},
+	}
+}
+
+func stockQuery(value bool, query H) H {
+	if value {
+		return query
+	}
+
+	return H{
+		"bool": H{
+			"must_not": []H{query},
+		},
+	}
+}
+
+// parseStockValue value is "<shop_id>[,<shop_id>]:<true|false>"
+func parseStockValue(input string) ([]string, bool, error) {
+
+	parts := strings.SplitN(input, shopValueFilterSeparator, 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, false, ErrInvalidFilterValue
+	}
+
+	value, err := strconv.ParseBool(parts[1])
+	if err != nil {
+		return nil, false, ErrInvalidFilterValue
+	}
+
+	return strings.Split(parts[0], ","), value, nil
 }
@@ -0,0 +1,141 @@
+package elastic
+
+import (
+	"encoding/json"
+	"errors"
+	"genproto/catalog_service"
+	"genproto/common"
+	"testing"
+)
+
+func TestMakeFilterClauses(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		req      *catalog_service.GetAllProductsRequest
+		want     []string
+		negative []bool
+		err      error
+	}{
+		{
+			name: "no filters",
+			req:  &catalog_service.GetAllProductsRequest{},
+		},
+		{
+			name: "categories and measurement units",
+			req: &catalog_service.GetAllProductsRequest{
+				CategoryIds:    []string{"c1"},
+				MeasurementIds: []string{"m1", "m2"},
+			},
+			want: []string{
+				`{"terms":{"categories.id.keyword":["c1"]}}`,
+				`{"terms":{"measurement_unit.id.keyword":["m1","m2"]}}`,
+			},
+			negative: []bool{false, false},
+		},
+		{
+			name: "category filter with children",
+			req: &catalog_service.GetAllProductsRequest{
+				Filters: []*common.FilterField{{Key: "category", Relation: common.Relation_INCLUDE, Value: "c1,c2"}},
+			},
+			want:     []string{`{"bool":{"should":[{"terms":{"categories.parent_id.keyword":["c1","c2"]}},{"terms":{"categories.id.keyword":["c1","c2"]}}]}}`},
+			negative: []bool{false},
+		},
+		{
+			name: "not included supplier",
+			req: &catalog_service.GetAllProductsRequest{
+				Filters: []*common.FilterField{{Key: "supplier", Relation: common.Relation_NOT_INCLUDE, Value: "s1,s2"}},
+			},
+			want:     []string{`{"terms":{"supplier.id.keyword":["s1","s2"]}}`},
+			negative: []bool{true},
+		},
+		{
+			name: "retail price greater than",
+			req: &catalog_service.GetAllProductsRequest{
+				Filters: []*common.FilterField{{Key: "retail_price", Relation: common.Relation_GREATER_THAN, Value: "shop:1000"}},
+			},
+			want:     []string{`{"nested":{"ignore_unmapped":true,"path":"shops","query":{"bool":{"filter":[{"terms":{"shops.shop_id":["shop"]}},{"range":{"shops.retail_price":{"gt":1000}}}]}}}}`},
+			negative: []bool{false},
+		},
+		{
+			name: "out of stock in all shops",
+			req: &catalog_service.GetAllProductsRequest{
+				Filters: []*common.FilterField{{Key: "out_of_stock", Relation: common.Relation_EQUAL, Value: "s1,s2:true"}},
+			},
+			want:     []string{`{"bool":{"must_not":[{"nested":{"ignore_unmapped":true,"path":"shops","query":{"bool":{"filter":[{"terms":{"shops.shop_id":["s1","s2"]}},{"range":{"shops.amount":{"gt":0}}}]}}}}]}}`},
+			negative: []bool{false},
+		},
+		{
+			name: "in stock in any shop",
+			req: &catalog_service.GetAllProductsRequest{
+				Filters: []*common.FilterField{{Key: "out_of_stock", Relation: common.Relation_EQUAL, Value: "s1:false"}},
+			},
+			want:     []string{`{"nested":{"ignore_unmapped":true,"path":"shops","query":{"bool":{"filter":[{"terms":{"shops.shop_id":["s1"]}},{"range":{"shops.amount":{"gt":0}}}]}}}}`},
+			negative: []bool{false},
+		},
+		{
+			name: "variants",
+			req: &catalog_service.GetAllProductsRequest{
+				Filters: []*common.FilterField{{Key: "is_variant", Relation: common.Relation_EQUAL, Value: "false"}},
+			},
+			want:     []string{`{"term":{"parent_id.keyword":""}}`},
+			negative: []bool{false},
+		},
+		{
+			name: "unknown filter",
+			req: &catalog_service.GetAllProductsRequest{
+				Filters: []*common.FilterField{{Key: "color", Relation: common.Relation_EQUAL, Value: "red"}},
+			},
+			err: ErrFilterNotFound,
+		},
+		{
+			name: "relation not supported",
+			req: &catalog_service.GetAllProductsRequest{
+				Filters: []*common.FilterField{{Key: "is_marking", Relation: common.Relation_GREATER_THAN, Value: "true"}},
+			},
+			err: ErrRelationNotSupported,
+		},
+		{
+			name: "invalid shop value",
+			req: &catalog_service.GetAllProductsRequest{
+				Filters: []*common.FilterField{{Key: "amount", Relation: common.Relation_EQUAL, Value: "10"}},
+			},
+			err: ErrInvalidFilterValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clauses, err := makeFilterClauses(tt.req)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("makeFilterClauses() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("makeFilterClauses() error = %v", err)
+			}
+
+			if len(clauses) != len(tt.want) {
+				t.Fatalf("makeFilterClauses() returned %d clauses, want %d", len(clauses), len(tt.want))
+			}
+
+			for i, clause := range clauses {
+				got, err := json.Marshal(clause.query)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(got) != tt.want[i] {
+					t.Errorf("clause %d query = %s, want %s", i, got, tt.want[i])
+				}
+
+				if clause.negative != tt.negative[i] {
+					t.Errorf("clause %d negative = %v, want %v", i, clause.negative, tt.negative[i])
+				}
+			}
+		})
+	}
+}
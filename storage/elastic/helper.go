@@ -2,7 +2,6 @@ package elastic
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/elastic/go-elasticsearch/v8"
@@ -53,24 +52,132 @@ func splitSearchInput(input string) []H {
 
 }
 
-func getSearchString(input string) string {
+// getSearchTerms splits the input by spaces and query string special characters
+func getSearchTerms(input string) []string {
 	specialCharacteristics := []string{"-", "+", "=", "&&", "||", ">", "<", "!", "(", ")", "{", "}", "[", "]", "^", `"`, "~", "*", "?", ":", `\`, "/"}
 
 	for _, ch := range specialCharacteristics {
 		input = strings.ReplaceAll(input, ch, " ")
 	}
 
-	re := regexp.MustCompile(`\s+`)
-	input = re.ReplaceAllString(input, " ")
-	input = strings.TrimSpace(input)
+	return strings.Fields(input)
+}
+
+// searchTermShould matches the term inside name or sku by their ngram sub fields.
+// Terms out of ngram lengths are matched by name words, sku and barcodes
+func searchTermShould(term string) []H {
+	return []H{
+		{"match": H{"name.ngram": term}},
+		{"match": H{"sku.ngram": term}},
+		{"match": H{"name.edge_ngram": term}},
+		{"match": H{"name": term}},
+		{"term": H{"sku": term}},
+		{"prefix": H{"barcodes": term}},
+	}
+}
+
+// searchShould matches the input when each term is found in the product or, by name.translit,
+// the input is typed in the other script. The whole name typed in any case is ranked first
+func searchShould(input string) []H {
+
+	var (
+		terms = getSearchTerms(input)
+		must  = make([]H, 0, len(terms))
+	)
 
-	strArr := strings.Split(input, " ")
+	for _, term := range terms {
+		must = append(must, H{
+			"bool": H{
+				"should":               searchTermShould(term),
+				"minimum_should_match": 1,
+			},
+		})
+	}
 
-	for i := 0; i < len(strArr); i++ {
-		strArr[i] = fmt.Sprintf("*%s*", strArr[i])
+	should := []H{
+		{
+			"match": H{
+				"name.translit": H{
+					"query":    input,
+					"operator": "and",
+				},
+			},
+		},
+		{
+			"term": H{
+				"name.lowercase": H{
+					"value": strings.TrimSpace(input),
+					"boost": 10,
+				},
+			},
+		},
 	}
 
-	result := strings.Join(strArr, " ")
+	// input of special characters only must not match every product
+	if len(must) > 0 {
+		should = append(should, H{
+			"bool": H{
+				"must": must,
+			},
+		})
+	}
 
-	return result
+	return should
+}
+
+func searchQuery(input string) H {
+	return H{
+		"bool": H{
+			"should":               searchShould(input),
+			"minimum_should_match": 1,
+		},
+	}
+}
+
+// fuzzySearchQuery ranks exact sku and barcode hits first, then name matches allowing typos
+func fuzzySearchQuery(input string) H {
+
+	should := append(searchShould(input),
+		H{
+			"term": H{
+				"sku": H{
+					"value": input,
+					"boost": 20,
+				},
+			},
+		},
+		H{
+			"term": H{
+				"barcodes": H{
+					"value": input,
+					"boost": 20,
+				},
+			},
+		},
+		H{
+			"match": H{
+				"name": H{
+					"query":     input,
+					"operator":  "and",
+					"fuzziness": "AUTO",
+				},
+			},
+		},
+		H{
+			"match": H{
+				"name.translit": H{
+					"query":     input,
+					"operator":  "and",
+					"fuzziness": "AUTO",
+				},
+			},
+		},
+	)
+
+	return H{
+		"bool": H{
+			"should":               should,
+			"minimum_should_match": 1,
+		},
+	}
 }
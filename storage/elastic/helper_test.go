@@ -0,0 +1,74 @@
+package elastic
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetSearchTerms(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "words", input: "coca cola", want: []string{"coca", "cola"}},
+		{name: "extra spaces", input: "  coca   cola ", want: []string{"coca", "cola"}},
+		{name: "special characters", input: "coca-cola (0.5)", want: []string{"coca", "cola", "0.5"}},
+		{name: "wildcards", input: "*cola?", want: []string{"cola"}},
+		{name: "special characters only", input: "*-/", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getSearchTerms(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getSearchTerms(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		input  string
+		want   []string
+		ignore []string
+	}{
+		{
+			name:   "terms use ngram sub fields",
+			input:  "cola 0.5",
+			want:   []string{`"name.ngram":"cola"`, `"sku.ngram":"0.5"`, `"name.lowercase":{"boost":10,"value":"cola 0.5"}`},
+			ignore: []string{"query_string", "*cola*"},
+		},
+		{
+			name:   "special characters only",
+			input:  "*",
+			ignore: []string{"query_string", "name.ngram", `"must"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := json.Marshal(searchQuery(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(string(query), want) {
+					t.Errorf("searchQuery(%q) = %s, want %s in it", tt.input, query, want)
+				}
+			}
+
+			for _, ignore := range tt.ignore {
+				if strings.Contains(string(query), ignore) {
+					t.Errorf("searchQuery(%q) = %s, want no %s in it", tt.input, query, ignore)
+				}
+			}
+		})
+	}
+}
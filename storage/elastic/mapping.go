@@ -0,0 +1,302 @@
+package elastic
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Invan2/invan_catalog_service/config"
+)
+
+// productMappingVersion must be increased on every change of productIndexTemplate,
+// indices created before keep their mapping until they are rebuilt with reindex
+const productMappingVersion = 6
+
+// minProductMappingVersion is the oldest mapping queries work with, nested shops came with 4
+// and brand and tags with 6. The service does not start on an older index, it must be rebuilt with reindex first
+const minProductMappingVersion = 6
+
+// cyrillicToLatin follows the uzbek latin alphabet, apostrophes of o' and g' are dropped
+// so both scripts and any apostrophe variant are indexed the same
+var cyrillicToLatin = map[string]string{
+	"а": "a", "б": "b", "в": "v", "г": "g", "д": "d", "е": "e", "ё": "yo", "ж": "j",
+	"з": "z", "и": "i", "й": "y", "к": "k", "л": "l", "м": "m", "н": "n", "о": "o",
+	"п": "p", "р": "r", "с": "s", "т": "t", "у": "u", "ф": "f", "х": "x", "ц": "ts",
+	"ч": "ch", "ш": "sh", "щ": "sh", "ъ": "", "ы": "i", "ь": "", "э": "e", "ю": "yu",
+	"я": "ya", "ў": "o", "қ": "q", "ғ": "g", "ҳ": "h",
+	"'": "", "`": "", "ʻ": "", "ʼ": "", "‘": "", "’": "",
+}
+
+func transliterationMappings() []string {
+
+	var res = make([]string, 0, len(cyrillicToLatin)*2)
+
+	for from, to := range cyrillicToLatin {
+		res = append(res, from+"=>"+to)
+
+		if upper := strings.ToUpper(from); upper != from {
+			res = append(res, upper+"=>"+to)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// keywordField keeps the keyword sub field to serve queries written against the dynamic mapping
+func keywordField() H {
+	return H{
+		"type": "keyword",
+		"fields": H{
+			"keyword": H{
+				"type": "keyword",
+			},
+		},
+	}
+}
+
+func textField() H {
+	return H{
+		"type":     "text",
+		"analyzer": "product_text",
+		"fields": H{
+			"keyword": H{
+				"type":         "keyword",
+				"ignore_above": 256,
+			},
+		},
+	}
+}
+
+func productIndexTemplate() H {
+	return H{
+		"index_patterns": []string{config.ElasticProductIndex, config.ElasticProductIndex + "_*"},
+		"version":        productMappingVersion,
+		"template": H{
+			"settings": H{
+				"index": H{
+					"max_ngram_diff": 8,
+				},
+				"analysis": H{
+					"char_filter": H{
+						"cyrillic_to_latin": H{
+							"type":     "mapping",
+							"mappings": transliterationMappings(),
+						},
+					},
+					"filter": H{
+						"product_edge_ngram": H{
+							"type":     "edge_ngram",
+							"min_gram": 1,
+							"max_gram": 20,
+						},
+						"product_ngram": H{
+							"type":     "ngram",
+							"min_gram": 2,
+							"max_gram": 10,
+						},
+					},
+					"analyzer": H{
+						"product_text": H{
+							"type":      "custom",
+							"tokenizer": "standard",
+							"filter":    []string{"lowercase", "asciifolding"},
+						},
+						"product_edge_ngram": H{
+							"type":      "custom",
+							"tokenizer": "standard",
+							"filter":    []string{"lowercase", "asciifolding", "product_edge_ngram"},
+						},
+						"product_ngram": H{
+							"type":      "custom",
+							"tokenizer": "keyword",
+							"filter":    []string{"lowercase", "asciifolding", "product_ngram"},
+						},
+						"product_translit": H{
+							"type":        "custom",
+							"char_filter": []string{"cyrillic_to_latin"},
+							"tokenizer":   "standard",
+							"filter":      []string{"lowercase", "asciifolding"},
+						},
+						"product_translit_edge_ngram": H{
+							"type":        "custom",
+							"char_filter": []string{"cyrillic_to_latin"},
+							"tokenizer":   "standard",
+							"filter":      []string{"lowercase", "asciifolding", "product_edge_ngram"},
+						},
+						"product_code": H{
+							"type":      "custom",
+							"tokenizer": "keyword",
+							"filter":    []string{"lowercase", "asciifolding"},
+						},
+					},
+					"normalizer": H{
+						"product_lowercase": H{
+							"type":   "custom",
+							"filter": []string{"lowercase", "asciifolding"},
+						},
+					},
+				},
+			},
+			"mappings": H{
+				"_meta": H{
+					"mapping_version": productMappingVersion,
+				},
+				"properties": H{
+					"id":              keywordField(),
+					"company_id":      keywordField(),
+					"parent_id":       keywordField(),
+					"product_type_id": keywordField(),
+					"name": H{
+						"type":     "text",
+						"analyzer": "product_text",
+						"fields": H{
+							"keyword": H{
+								"type": "keyword",
+							},
+							"lowercase": H{
+								"type":       "keyword",
+								"normalizer": "product_lowercase",
+							},
+							"edge_ngram": H{
+								"type":            "text",
+								"analyzer":        "product_edge_ngram",
+								"search_analyzer": "product_text",
+							},
+							"ngram": H{
+								"type":            "text",
+								"analyzer":        "product_ngram",
+								"search_analyzer": "product_code",
+							},
+							"translit": H{
+								"type":            "text",
+								"analyzer":        "product_translit_edge_ngram",
+								"search_analyzer": "product_translit",
+							},
+						},
+					},
+					"sku": H{
+						"type":       "keyword",
+						"normalizer": "product_lowercase",
+						"fields": H{
+							"keyword": H{
+								"type": "keyword",
+							},
+							"ngram": H{
+								"type":            "text",
+								"analyzer":        "product_ngram",
+								"search_analyzer": "product_code",
+							},
+						},
+					},
+					"barcodes": H{
+						"type":       "keyword",
+						"normalizer": "product_lowercase",
+						"fields": H{
+							"keyword": H{
+								"type": "keyword",
+							},
+						},
+					},
+					"mxik_code":   keywordField(),
+					"description": textField(),
+					"image": H{
+						"type":  "keyword",
+						"index": false,
+					},
+					"is_marking": H{
+						"type": "boolean",
+					},
+					"created_at": H{
+						"type":             "date",
+						"format":           "yyyy-MM-dd HH:mm:ss",
+						"ignore_malformed": true,
+						"fields": H{
+							"keyword": H{
+								"type": "keyword",
+							},
+						},
+					},
+					"updated_at": H{
+						"type": "double",
+						"fields": H{
+							"keyword": H{
+								"type": "keyword",
+							},
+						},
+					},
+					"categories": H{
+						"properties": H{
+							"id":        keywordField(),
+							"parent_id": keywordField(),
+							"name":      textField(),
+						},
+					},
+					"measurement_unit": H{
+						"properties": H{
+							"id":         keywordField(),
+							"short_name": textField(),
+							"long_name":  textField(),
+						},
+					},
+					"supplier": H{
+						"properties": H{
+							"id":   keywordField(),
+							"name": textField(),
+						},
+					},
+					"brand": H{
+						"properties": H{
+							"id":   keywordField(),
+							"name": textField(),
+						},
+					},
+					"tags": H{
+						"properties": H{
+							"id":   keywordField(),
+							"name": textField(),
+						},
+					},
+					"vat": H{
+						"properties": H{
+							"id":   keywordField(),
+							"name": textField(),
+							"percentage": H{
+								"type": "float",
+							},
+						},
+					},
+					"measurement_values": H{
+						"type": "flattened",
+					},
+					"shop_prices": H{
+						"type": "flattened",
+					},
+					"shops": H{
+						"type": "nested",
+						"properties": H{
+							"shop_id": H{
+								"type": "keyword",
+							},
+							"retail_price": H{
+								"type": "double",
+							},
+							"supply_price": H{
+								"type": "double",
+							},
+							"amount": H{
+								"type": "double",
+							},
+							"low_stock": H{
+								"type": "double",
+							},
+							"available": H{
+								"type": "boolean",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -44,35 +44,8 @@ func (p *productRepo) Create(product *catalog_service.ProductES) error {
 
 	if !exists(p.db, config.ElasticProductIndex) {
 
-		query := H{
-			"mappings": H{
-				"properties": H{
-					"measurement_values": H{
-						"type": "flattened",
-					},
-					"shop_prices": H{
-						"type": "flattened",
-					},
-					"updated_at": H{
-						"type": "text",
-						"fields": H{
-							"keyword": H{
-								"type": "keyword",
-							},
-						},
-					},
-				},
-			},
-		}
-
-		body, err := json.Marshal(query)
-		if err != nil {
-			return errors.Wrap(err, "error while marshaling mapping query")
-		}
-
 		esReq := esapi.IndicesCreateRequest{
 			Index: config.ElasticProductIndex,
-			Body:  bytes.NewReader(body),
 		}
 
 		res, err := esReq.Do(context.Background(), p.db)
@@ -91,16 +64,12 @@ func (p *productRepo) Create(product *catalog_service.ProductES) error {
 		}
 	}
 
-	var (
-		body bytes.Buffer
-	)
-
-	err := config.JSONPBMarshaler.Marshal(&body, product)
+	body, err := productDocument(product)
 	if err != nil {
-		return errors.Wrap(err, "error while marshaling, jsonpb")
+		return err
 	}
 
-	res, err := p.db.Create(config.ElasticProductIndex, product.Id, bytes.NewReader(body.Bytes()), p.db.Create.WithRefresh("true"))
+	res, err := p.db.Create(config.ElasticProductIndex, product.Id, bytes.NewReader(body), p.db.Create.WithRefresh("true"))
 	if err != nil {
 		return errors.Wrap(err, "Failed to bulk insert products")
 	}
@@ -137,17 +106,12 @@ func (p *productRepo) Update(product *catalog_service.ProductES) error {
 		}
 	}
 
-	var (
-		updateReq = catalog_service.UpdateProductES{Doc: product}
-		body      bytes.Buffer
-	)
-
-	err := config.JSONPBMarshaler.Marshal(&body, &updateReq)
+	body, err := mergeDocumentBody(product)
 	if err != nil {
-		return errors.Wrap(err, "error while marshaling jsonpb")
+		return err
 	}
 
-	res, err := p.db.Update(config.ElasticProductIndex, product.Id, bytes.NewReader(body.Bytes()), p.db.Update.WithRefresh("true"))
+	res, err := p.db.Update(config.ElasticProductIndex, product.Id, bytes.NewReader(body), p.db.Update.WithRefresh("true"))
 	if err != nil {
 		return errors.Wrap(err, "error while update document on elastic")
 	}
@@ -180,22 +144,6 @@ func makeGettAllSearchRequest(req *catalog_service.GetAllProductsRequest, statis
 		},
 	})
 
-	if len(req.CategoryIds) > 0 {
-		must = append(must, H{
-			"terms": H{
-				"categories.id.keyword": req.GetCategoryIds(),
-			},
-		})
-	}
-
-	if len(req.MeasurementIds) > 0 {
-		must = append(must, H{
-			"terms": H{
-				"measurement_unit.id.keyword": req.GetMeasurementIds(),
-			},
-		})
-	}
-
 	if len(req.ProductIds) > 0 {
 		must = append(must, H{
 			"terms": H{
@@ -205,53 +153,37 @@ func makeGettAllSearchRequest(req *catalog_service.GetAllProductsRequest, statis
 	}
 
 	if req.Search != "" {
-		must = append(must, H{
-			"query_string": H{
-				"query":            getSearchString(req.GetSearch()),
-				"default_operator": "AND",
-			},
-		})
+		must = append(must, searchQuery(req.GetSearch()))
 	}
 
-	for _, field := range req.Filters {
-
-		filterFunction, ok := filterFunctionMap[field.Key]
-		if !ok {
-			return nil, ErrFilterNotFound
-		}
-
-		query, err := filterFunction(field)
-		if err != nil {
-			return nil, errors.Wrap(err, "error while filterFunction")
-		}
+	clauses, err := makeFilterClauses(req)
+	if err != nil {
+		return nil, err
+	}
 
-		if field.Relation == common.Relation_EQUAL ||
-			field.Relation == common.Relation_GREATER_THAN ||
-			field.Relation == common.Relation_INCLUDE {
-			must = append(must, query)
+	for _, clause := range clauses {
+		if clause.negative {
+			mustNot = append(mustNot, clause.query)
 		} else {
-			mustNot = append(mustNot, query)
+			must = append(must, clause.query)
 		}
-
 	}
 
 	if len(must) > 0 {
 		bool["must"] = must
 	}
 
+	if len(mustNot) > 0 {
+		bool["must_not"] = mustNot
+	}
+
 	if len(bool) > 0 {
 		query["bool"] = bool
 	}
 
-	// Sort
-	sort := make([]H, 0)
-
-	if req.SortBy == "" {
-		sort = append(sort, H{
-			"updated_at.keyword": H{
-				"order": "desc",
-			},
-		})
+	sort, err := makeSort(req)
+	if err != nil {
+		return nil, err
 	}
 
 	var res = H{
@@ -261,72 +193,7 @@ func makeGettAllSearchRequest(req *catalog_service.GetAllProductsRequest, statis
 
 	if statistics {
 		aggs := H{
-			"total_retail_price": H{
-				"sum": H{
-					"script": H{
-						"lang": "painless",
-						"params": H{
-							"shop_measurement_values": "doc['shop_measurement_values']",
-							"shop_prices":             "doc['shop_prices']",
-						},
-						"source": `
-							double sum = 0;
-							if (
-								params == null ||
-								params._source == null ||
-								params._source['shop_measurement_values'] == null ||
-								params._source['shop_prices'] == null
-							) {
-								return sum;
-							}
-							for (key in params._source['shop_measurement_values'].entrySet()) {
-								if (
-									key.getValue() != null &&
-									key.getValue().amount != null &&
-									params._source['shop_prices'][key.getKey()] != null &&
-									params._source['shop_prices'][key.getKey()].retail_price != null
-								)
-									sum += key.getValue().amount * params._source['shop_prices'][key.getKey()].retail_price;
-							}
-							return sum
-						`,
-					},
-					"missing": 0,
-				},
-			},
-			"total_supply_price": H{
-				"sum": H{
-					"script": H{
-						"lang": "painless",
-						"params": H{
-							"shop_measurement_values": "doc['shop_measurement_values']",
-							"shop_prices":             "doc['shop_prices']",
-						},
-						"source": `
-							double sum = 0;
-							if (
-								params == null ||
-								params._source == null ||
-								params._source['shop_measurement_values'] == null ||
-								params._source['shop_prices'] == null
-							) {
-								return sum;
-							}
-							for (key in params._source['shop_measurement_values'].entrySet()) {
-								if (
-									key.getValue() != null &&
-									key.getValue().amount != null &&
-									params._source['shop_prices'][key.getKey()] != null &&
-									params._source['shop_prices'][key.getKey()].supply_price != null
-								)
-									sum += key.getValue().amount * params._source['shop_prices'][key.getKey()].supply_price;
-							}
-							return sum;
-						`,
-					},
-					"missing": 0,
-				},
-			},
+			"shops": shopsAggs(req.ShopIds, valuationAggs()),
 		}
 
 		res["aggs"] = aggs
@@ -419,16 +286,27 @@ func (p *productRepo) GetAll(entity *catalog_service.GetAllProductsRequest) (*ca
 			CreatedAt:         product.CreatedAt,
 			ShopPrices:        product.ShopPrices,
 			Categories:        product.Categories,
+			Brand:             product.Brand,
+			Tags:              product.Tags,
 		})
 
 	}
 
 	res.Total = int64(r["hits"].(map[string]interface{})["total"].(map[string]interface{})["value"].(float64))
 
-	res.Statistics.TotalRetailPrice = cast.ToUint64(r["aggregations"].(map[string]interface{})["total_retail_price"].(map[string]interface{})["value"])
-	res.Statistics.TotalSupplyPrice = cast.ToUint64(r["aggregations"].(map[string]interface{})["total_supply_price"].(map[string]interface{})["value"])
+	shops := r["aggregations"].(map[string]interface{})["shops"].(map[string]interface{})["selected"].(map[string]interface{})
+
+	res.Statistics.TotalRetailPrice = cast.ToUint64(shops["total_retail_price"].(map[string]interface{})["value"])
+	res.Statistics.TotalSupplyPrice = cast.ToUint64(shops["total_supply_price"].(map[string]interface{})["value"])
 	res.Statistics.NumberOfProducts = uint64(res.Total)
 
+	if len(entity.Facets) > 0 {
+		res.Facets, err = p.getFacets(entity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &res, nil
 }
 
@@ -447,13 +325,7 @@ func makeSearchRequest(req *catalog_service.GetAllProductsRequest) H {
 	})
 
 	if req.Search != "" {
-		must = append(must, H{
-			"query_string": H{
-				"query":            getSearchString(req.Search),
-				"default_operator": "AND",
-				"fields":           []string{"sku", "name", "barcodes"},
-			},
-		})
+		must = append(must, fuzzySearchQuery(req.Search))
 	}
 
 	if len(must) > 0 {
@@ -466,6 +338,14 @@ func makeSearchRequest(req *catalog_service.GetAllProductsRequest) H {
 
 	sort := make([]H, 0)
 
+	if req.SortBy == "" && req.Search != "" {
+		sort = append(sort, H{
+			"_score": H{
+				"order": "desc",
+			},
+		})
+	}
+
 	if req.SortBy == "" {
 		sort = append(sort, H{
 			"created_at.keyword": H{
@@ -559,6 +439,8 @@ func (p *productRepo) SearchProducts(entity *catalog_service.GetAllProductsReque
 			Description:       product.Description,
 			ShopPrices:        product.ShopPrices,
 			Categories:        product.Categories,
+			Brand:             product.Brand,
+			Tags:              product.Tags,
 			CreatedAt:         product.CreatedAt,
 		})
 
@@ -569,6 +451,123 @@ func (p *productRepo) SearchProducts(entity *catalog_service.GetAllProductsReque
 	return &res, nil
 }
 
+func (p *productRepo) SuggestProducts(req *catalog_service.SuggestProductsRequest) (*catalog_service.SuggestProductsResponse, error) {
+
+	var (
+		buf    bytes.Buffer
+		filter = []H{
+			{
+				"term": H{
+					"company_id.keyword": req.Request.CompanyId,
+				},
+			},
+		}
+		res = catalog_service.SuggestProductsResponse{
+			Suggestions: make([]*catalog_service.ProductSuggestion, 0),
+		}
+		r struct {
+			Hits struct {
+				Hits []struct {
+					Source *catalog_service.ProductSuggestion `json:"_source"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+	)
+
+	if req.ShopId != "" {
+		filter = append(filter, shopsQuery([]string{req.ShopId}, H{"term": H{"shops.available": true}}))
+	}
+
+	searchReq := H{
+		"_source": []string{"id", "name", "sku", "barcodes"},
+		"size":    req.Limit,
+		"query": H{
+			"bool": H{
+				"filter": filter,
+				"should": []H{
+					{
+						"prefix": H{
+							"sku": H{
+								"value": req.Prefix,
+								"boost": 5,
+							},
+						},
+					},
+					{
+						"prefix": H{
+							"barcodes": H{
+								"value": req.Prefix,
+								"boost": 5,
+							},
+						},
+					},
+					{
+						"match": H{
+							"name.edge_ngram": H{
+								"query":    req.Prefix,
+								"operator": "and",
+								"boost":    2,
+							},
+						},
+					},
+					{
+						"match": H{
+							"name.translit": H{
+								"query":    req.Prefix,
+								"operator": "and",
+							},
+						},
+					},
+					{
+						"match": H{
+							"name": H{
+								"query":     req.Prefix,
+								"operator":  "and",
+								"fuzziness": "AUTO",
+							},
+						},
+					},
+				},
+				"minimum_should_match": 1,
+			},
+		},
+	}
+
+	if err := json.NewEncoder(&buf).Encode(searchReq); err != nil {
+		return nil, errors.Wrap(err, "error while encode")
+	}
+
+	response, err := p.db.Search(
+		p.db.Search.WithContext(context.Background()),
+		p.db.Search.WithIndex(config.ElasticProductIndex),
+		p.db.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get documents on elastic")
+	}
+	defer response.Body.Close()
+
+	if response.IsError() {
+		data, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		p.log.Error("errror while suggest products ", logger.Any("res", string(data)))
+		return nil, errors.New("error while suggest products on elastic " + string(data))
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&r); err != nil {
+		return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
+	}
+
+	for _, hit := range r.Hits.Hits {
+		res.Suggestions = append(res.Suggestions, hit.Source)
+	}
+
+	return &res, nil
+}
+
 func (p *productRepo) DeleteProduct(req *common.RequestID) (*common.Empty, error) {
 
 	res, err := p.db.Delete(
@@ -633,92 +632,6 @@ func (p *productRepo) DeleteProducts(req *common.RequestIDs) (*common.Empty, err
 	return &common.Empty{}, nil
 }
 
-func (p *productRepo) getOrderProducts(order *common.OrderCopyRequest) (map[string]*catalog_service.ProductES, error) {
-
-	var (
-		res    = make(map[string]*catalog_service.ProductES)
-		r      map[string]interface{}
-		should []H = make([]H, 0)
-		buf    bytes.Buffer
-	)
-
-	for _, orderItem := range order.Items {
-		should = append(should, H{"term": H{"_id": orderItem.ProductId}})
-	}
-
-	req := H{
-		"query": H{
-			"bool": H{
-				"should": should,
-			},
-		},
-	}
-
-	if err := json.NewEncoder(&buf).Encode(req); err != nil {
-		return nil, err
-	}
-
-	response, err := p.db.Search(
-		p.db.Search.WithContext(context.Background()),
-		p.db.Search.WithIndex(config.ElasticProductIndex),
-		p.db.Search.WithBody(&buf),
-		p.db.Search.WithTrackTotalHits(true),
-	)
-	if err != nil || (response != nil && response.IsError()) {
-		return nil, errors.New("error while get documents on elastic")
-	}
-
-	if response.IsError() {
-		data, err := io.ReadAll(response.Body)
-		if err != nil {
-			return nil, err
-		}
-
-		p.log.Error("errror while get  products for orders ", logger.Any("res", string(data)))
-		return nil, errors.New("error while get producs for order on elastic " + string(data))
-	}
-
-	err = json.NewDecoder(response.Body).Decode(&r)
-	if err != nil {
-		return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
-	}
-
-	for _, source := range r["hits"].(map[string]interface{})["hits"].([]interface{}) {
-		product := catalog_service.ProductES{}
-
-		jsonString, _ := json.Marshal(source.(map[string]interface{})["_source"])
-
-		err = json.Unmarshal(jsonString, &product)
-		if err != nil {
-			return nil, errors.Wrap(err, "error while json.Unmarshal jsonString &product")
-		}
-
-		res[product.Id] = &catalog_service.ProductES{
-			Id:                product.Id,
-			CompanyId:         product.CompanyId,
-			Sku:               product.Sku,
-			Name:              product.Name,
-			MeasurementUnit:   product.MeasurementUnit,
-			Supplier:          product.Supplier,
-			Vat:               product.Vat,
-			ParentId:          product.ParentId,
-			Barcodes:          product.Barcodes,
-			ProductTypeId:     product.ProductTypeId,
-			Image:             product.Image,
-			MxikCode:          product.MxikCode,
-			IsMarking:         product.IsMarking,
-			MeasurementValues: product.MeasurementValues,
-			Description:       product.Description,
-			CreatedAt:         product.CreatedAt,
-			ShopPrices:        product.ShopPrices,
-			Categories:        product.Categories,
-			CreatedBy:         product.CreatedBy,
-		}
-	}
-
-	return res, nil
-}
-
 func (p *productRepo) getSupplierOrderProducts(order *catalog_service.UpsertShopMeasurmentValueRequest) (map[string]*catalog_service.ProductES, error) {
 
 	var (
@@ -797,6 +710,8 @@ func (p *productRepo) getSupplierOrderProducts(order *catalog_service.UpsertShop
 			CreatedAt:         product.CreatedAt,
 			ShopPrices:        product.ShopPrices,
 			Categories:        product.Categories,
+			Brand:             product.Brand,
+			Tags:              product.Tags,
 			CreatedBy:         product.CreatedBy,
 		}
 	}
@@ -843,7 +758,7 @@ func (p *productRepo) UpsertShopMeasurmentValue(req *catalog_service.UpsertShopM
 			},
 		},
 		"script": H{
-			"source": "ctx._source.measurement_values[params.products[ctx._source.id].shop_id] = params.products[ctx._source.id]",
+			"source": "ctx._source.measurement_values[params.products[ctx._source.id].shop_id] = params.products[ctx._source.id];" + syncShopsScript,
 			"lang":   "painless",
 			"params": H{
 				"products": produtcAmountMap,
@@ -914,7 +829,7 @@ func (p *productRepo) UpsertShopPrice(req *catalog_service.UpsertShopPriceReques
 			},
 		},
 		"script": H{
-			"source": "ctx._source.shop_prices[params.products[ctx._source.id].shop_id] = params.products[ctx._source.id]",
+			"source": "ctx._source.shop_prices[params.products[ctx._source.id].shop_id] = params.products[ctx._source.id];" + syncShopsScript,
 			"lang":   "painless",
 			"params": H{
 				"products": prodcutsPriceMap,
@@ -950,51 +865,84 @@ func (p *productRepo) UpsertShopPrice(req *catalog_service.UpsertShopPriceReques
 	return nil
 }
 
-func (p *productRepo) UpdateOnOrder(order *common.OrderCopyRequest) error {
+func (p *productRepo) ApplyStockDeltas(deltas []*models.ShopStockDelta) error {
+
+	var (
+		productIds = make([]string, 0)
+		deltaMap   = make(map[string][]*models.ShopStockDelta)
+	)
+
+	if len(deltas) == 0 {
+		return nil
+	}
+
 	if !exists(p.db, config.ElasticProductIndex) {
-		res, err := p.db.Indices.Create(config.ElasticProductIndex)
-		if err != nil {
-			return errors.Wrap(err, "error while create index")
-		}
+		return nil
+	}
 
-		if err := checkResponseCodeToSuccess(res.StatusCode); err != nil {
-			return err
+	for _, delta := range deltas {
+		if _, ok := deltaMap[delta.ProductId]; !ok {
+			productIds = append(productIds, delta.ProductId)
 		}
-	}
 
-	productsMap, err := p.getOrderProducts(order)
-	if err != nil {
-		return errors.Wrap(err, "error while getting order prodcuts")
+		deltaMap[delta.ProductId] = append(deltaMap[delta.ProductId], delta)
 	}
 
-	for _, orderItem := range order.Items {
+	query := H{
+		"query": H{
+			"terms": H{
+				"id.keyword": productIds,
+			},
+		},
+		"script": H{
+			"source": `
+				if (ctx._source.measurement_values == null) {
+					ctx._source.measurement_values = new HashMap();
+				}
+
+				for (delta in params.deltas[ctx._source.id]) {
+					def value = ctx._source.measurement_values[delta.shop_id];
+					if (value == null) {
+						value = ['shop_id': delta.shop_id, 'amount': 0.0, 'small_left': 0.0, 'has_trigger': false, 'is_available': true];
+						ctx._source.measurement_values[delta.shop_id] = value;
+					}
+
+					value.amount = (value.amount == null ? 0.0 : value.amount) + delta.amount;
+					value.total_sold = (value.total_sold == null ? 0.0 : value.total_sold) + delta.total_sold;
+					value.total_transfered = (value.total_transfered == null ? 0.0 : value.total_transfered) + delta.total_transfered;
+					value.total_transfer_arrived = (value.total_transfer_arrived == null ? 0.0 : value.total_transfer_arrived) + delta.total_transfer_arrived;
+				}
+			` + syncShopsScript,
+			"lang": "painless",
+			"params": H{
+				"deltas": deltaMap,
+			},
+		},
+	}
 
-		if productsMap[orderItem.ProductId] == nil || productsMap[orderItem.ProductId].MeasurementValues[order.ShopId] == nil {
-			p.log.Error("errror while update product. Create order copy. product not found", logger.Any("shopId", order.ShopId))
-			continue
-		}
-		productsMap[orderItem.ProductId].MeasurementValues[order.ShopId].Amount -= orderItem.Value
+	body, err := json.Marshal(query)
+	if err != nil {
+		return err
+	}
 
-		var body bytes.Buffer
+	request := esapi.UpdateByQueryRequest{
+		Index: []string{config.ElasticProductIndex},
+		Body:  strings.NewReader(string(body)),
+	}
 
-		err := config.JSONPBMarshaler.Marshal(&body, &catalog_service.UpdateProductES{Doc: productsMap[orderItem.ProductId]})
-		if err != nil {
-			return errors.Wrap(err, "error while marshaling")
-		}
+	res, err := request.Do(context.Background(), p.db)
+	if err != nil {
+		return err
+	}
 
-		res, err := p.db.Update(config.ElasticProductIndex, orderItem.ProductId, bytes.NewReader(body.Bytes()))
+	if res.IsError() {
+		data, err := io.ReadAll(res.Body)
 		if err != nil {
-			return errors.Wrap(err, "error while update document on elastic")
+			return err
 		}
 
-		if res.IsError() {
-			data, err := io.ReadAll(res.Body)
-			if err != nil {
-				return err
-			}
-
-			p.log.Error("errror while update product. Create order copy ", logger.Any("res", string(data)), logger.Any("statusCode", res.StatusCode))
-		}
+		p.log.Error("errror while apply stock deltas", logger.Any("res", string(data)))
+		return errors.New("error while apply stock deltas " + string(data))
 	}
 
 	return nil
@@ -1055,39 +1003,38 @@ func (p *productRepo) InsertMany(products []*common.CreateProductCopyRequest) er
 
 		}
 
-		err := config.JSONPBMarshaler.Marshal(&body, &catalog_service.UpsertProductES{
-			Doc: &catalog_service.ProductES{
-				Id:                product.Id,
-				Sku:               product.Sku,
-				Name:              product.Name,
-				Image:             product.Image,
-				IsMarking:         product.IsMarking,
-				MxikCode:          product.MxikCode,
-				ParentId:          product.ParentId,
-				CompanyId:         product.Request.CompanyId,
-				Description:       product.Description,
-				ProductTypeId:     product.ProductTypeId,
-				Barcodes:          product.Barcode,
-				ShopPrices:        shopPrices,
-				CreatedAt:         time.Now().Format(config.DateTimeFormat),
-				UpdatedAt:         float64(time.Now().UnixMilli()),
-				MeasurementValues: shopMeasurementValues,
-				Supplier: &catalog_service.ShortSupplier{
-					Id: product.SupplierId,
-				},
-				Vat: &catalog_service.ShortVat{
-					Id: product.VatId,
-				},
-				MeasurementUnit: &catalog_service.ShortMeasurementUnit{
-					Id: product.MeasurementUnitId,
-				},
+		document, err := mergeDocumentBody(&catalog_service.ProductES{
+			Id:                product.Id,
+			Sku:               product.Sku,
+			Name:              product.Name,
+			Image:             product.Image,
+			IsMarking:         product.IsMarking,
+			MxikCode:          product.MxikCode,
+			ParentId:          product.ParentId,
+			CompanyId:         product.Request.CompanyId,
+			Description:       product.Description,
+			ProductTypeId:     product.ProductTypeId,
+			Barcodes:          product.Barcode,
+			ShopPrices:        shopPrices,
+			CreatedAt:         time.Now().Format(config.DateTimeFormat),
+			UpdatedAt:         float64(time.Now().UnixMilli()),
+			MeasurementValues: shopMeasurementValues,
+			Supplier: &catalog_service.ShortSupplier{
+				Id: product.SupplierId,
+			},
+			Vat: &catalog_service.ShortVat{
+				Id: product.VatId,
+			},
+			MeasurementUnit: &catalog_service.ShortMeasurementUnit{
+				Id: product.MeasurementUnitId,
 			},
-			DocAsUpsert: true,
 		})
 		if err != nil {
-			return errors.Wrap(err, "error while marshaling, jsonpb")
+			return err
 		}
 
+		body.Write(document)
+
 		meta := []byte(fmt.Sprintf(`{ "update": { "_index": "%s", "_id" : "%s", "retry_on_conflict": 3 } }%s`, config.ElasticProductIndex, product.Id, "\n"))
 
 		body.Grow(len("\n"))
@@ -1121,195 +1068,3 @@ func (p *productRepo) InsertMany(products []*common.CreateProductCopyRequest) er
 
 	return nil
 }
-
-func (p *productRepo) GetAllForExcel(req *catalog_service.GetAllProductsRequest) (*models.GetAllForExcelResponse, error) {
-
-	var (
-		res = models.GetAllForExcelResponse{
-			Data:  make([]map[string]interface{}, 0),
-			Total: 0,
-		}
-		r    map[string]interface{}
-		buf  bytes.Buffer
-		size = int(req.Limit)
-		from = int((req.Page - 1) * req.Limit)
-	)
-
-	searchReq, err := makeGettAllSearchRequest(req, false)
-	if err != nil {
-		return nil, errors.Wrap(err, "error while getting searching products")
-	}
-
-	if err := json.NewEncoder(&buf).Encode(searchReq); err != nil {
-		return nil, errors.Wrap(err, "error while encode")
-	}
-
-	response, err := p.db.Search(
-		p.db.Search.WithContext(context.Background()),
-		p.db.Search.WithIndex(config.ElasticProductIndex),
-		p.db.Search.WithBody(&buf),
-		p.db.Search.WithTrackTotalHits(true),
-		p.db.Search.WithFrom(from),
-		p.db.Search.WithSize(size),
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, "error while get documents on elastic")
-	}
-
-	if response.IsError() {
-		data, err := io.ReadAll(response.Body)
-		if err != nil {
-			return nil, errors.Wrap(err, "error while reading data")
-		}
-
-		p.log.Error("errror while get all products ", logger.Any("res", string(data)))
-		return nil, errors.New("error while get  products on elastic " + string(data))
-	}
-
-	err = json.NewDecoder(response.Body).Decode(&r)
-	if err != nil {
-		return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
-	}
-
-	for _, source := range r["hits"].(map[string]interface{})["hits"].([]interface{}) {
-
-		var data = make(map[string]interface{})
-
-		product := catalog_service.ProductES{}
-
-		jsonString, _ := json.Marshal(source.(map[string]interface{})["_source"])
-
-		err = json.Unmarshal(jsonString, &product)
-		if err != nil {
-			return nil, errors.Wrap(err, "error while json.Unmarshal jsonString &product")
-		}
-
-		if product.Image != "" {
-			product.Image = fmt.Sprintf("https://%s/%s/%s", p.cfg.MinioEndpoint, config.FileBucketName, product.Image)
-		}
-
-		data["product_id"] = product.Id
-		data["name"] = product.Name
-		data["sku"] = product.Sku
-		data["mxik_code"] = product.MxikCode
-		data["barcode"] = strings.Join(product.Barcodes, ", ")
-		data["category"] = ""
-
-		for _, category := range product.Categories {
-			data["category"] = fmt.Sprintf("%s%s, ", data["category"], category.Name)
-		}
-
-		for _, shop := range product.ShopPrices {
-			data[fmt.Sprintf("supply_price(%s)", shop.ShopName)] = shop.SupplyPrice
-			data[fmt.Sprintf("retail_price(%s)", shop.ShopName)] = shop.RetailPrice
-
-		}
-
-		for _, measurementValue := range product.MeasurementValues {
-			data[fmt.Sprintf("amount(%s)", measurementValue.ShopName)] = measurementValue.Amount
-			data[fmt.Sprintf("low_stock(%s)", measurementValue.ShopName)] = measurementValue.SmallLeft
-		}
-
-		res.Data = append(res.Data, data)
-	}
-
-	res.Total = int64(r["hits"].(map[string]interface{})["total"].(map[string]interface{})["value"].(float64))
-
-	return &res, nil
-}
-
-func (p *productRepo) GetAllForCSV(req *catalog_service.GetAllProductsRequest) (*models.GetAllForCsvResponse, error) {
-
-	var (
-		res = models.GetAllForCsvResponse{
-			Data:  make([]map[string]interface{}, 0),
-			Total: 0,
-		}
-		r    map[string]interface{}
-		buf  bytes.Buffer
-		size = int(req.Limit)
-		from = int((req.Page - 1) * req.Limit)
-	)
-
-	searchReq, err := makeGettAllSearchRequest(req, false)
-	if err != nil {
-		return nil, errors.Wrap(err, "error while getting searching products")
-	}
-
-	if err := json.NewEncoder(&buf).Encode(searchReq); err != nil {
-		return nil, errors.Wrap(err, "error while encode")
-	}
-
-	response, err := p.db.Search(
-		p.db.Search.WithContext(context.Background()),
-		p.db.Search.WithIndex(config.ElasticProductIndex),
-		p.db.Search.WithBody(&buf),
-		p.db.Search.WithTrackTotalHits(true),
-		p.db.Search.WithFrom(from),
-		p.db.Search.WithSize(size),
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, "error while get documents on elastic")
-	}
-
-	if response.IsError() {
-		data, err := io.ReadAll(response.Body)
-		if err != nil {
-			return nil, errors.Wrap(err, "error while reading data")
-		}
-
-		p.log.Error("errror while get all products ", logger.Any("res", string(data)))
-		return nil, errors.New("error while get  products on elastic " + string(data))
-	}
-
-	err = json.NewDecoder(response.Body).Decode(&r)
-	if err != nil {
-		return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
-	}
-
-	for _, source := range r["hits"].(map[string]interface{})["hits"].([]interface{}) {
-
-		var data = make(map[string]interface{})
-
-		product := catalog_service.ProductES{}
-
-		jsonString, _ := json.Marshal(source.(map[string]interface{})["_source"])
-
-		err = json.Unmarshal(jsonString, &product)
-		if err != nil {
-			return nil, errors.Wrap(err, "error while json.Unmarshal jsonString &product")
-		}
-
-		if product.Image != "" {
-			product.Image = fmt.Sprintf("https://%s/%s/%s", p.cfg.MinioEndpoint, config.FileBucketName, product.Image)
-		}
-
-		data["product_id"] = product.Id
-		data["name"] = product.Name
-		data["sku"] = product.Sku
-		data["mxik_code"] = product.MxikCode
-		data["barcode"] = strings.Join(product.Barcodes, ", ")
-		data["category"] = ""
-
-		for _, category := range product.Categories {
-			data["category"] = fmt.Sprintf("%s%s, ", data["category"], category.Name)
-		}
-
-		for _, shop := range product.ShopPrices {
-			data[fmt.Sprintf("supply_price(%s)", shop.ShopName)] = shop.SupplyPrice
-			data[fmt.Sprintf("retail_price(%s)", shop.ShopName)] = shop.RetailPrice
-
-		}
-
-		for _, measurementValue := range product.MeasurementValues {
-			data[fmt.Sprintf("amount(%s)", measurementValue.ShopName)] = measurementValue.Amount
-			data[fmt.Sprintf("low_stock(%s)", measurementValue.ShopName)] = measurementValue.SmallLeft
-		}
-
-		res.Data = append(res.Data, data)
-	}
-
-	res.Total = int64(r["hits"].(map[string]interface{})["total"].(map[string]interface{})["value"].(float64))
-
-	return &res, nil
-}
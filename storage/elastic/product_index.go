@@ -0,0 +1,627 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"genproto/catalog_service"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/clarketm/json"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/Invan2/invan_catalog_service/storage/repo"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/pkg/errors"
+)
+
+const productIdsPageSize = 1000
+
+type productIndexRepo struct {
+	db  *elasticsearch.Client
+	log logger.Logger
+}
+
+func NewProductIndexRepo(log logger.Logger, db *elasticsearch.Client) repo.ProductIndexESI {
+	return &productIndexRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (p *productIndexRepo) readError(res *esapi.Response, msg string) error {
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrap(err, "error while reading data")
+	}
+
+	p.log.Error(msg, logger.Any("res", string(data)))
+	return errors.New(msg + " " + string(data))
+}
+
+// EnsureTemplate installs the products index template unless the same or a newer version is installed
+func (p *productIndexRepo) EnsureTemplate() error {
+
+	var r struct {
+		IndexTemplates []struct {
+			IndexTemplate struct {
+				Version int `json:"version"`
+			} `json:"index_template"`
+		} `json:"index_templates"`
+	}
+
+	res, err := p.db.Indices.GetIndexTemplate(p.db.Indices.GetIndexTemplate.WithName(config.ElasticProductIndex))
+	if err != nil {
+		return errors.Wrap(err, "error while get index template")
+	}
+	defer res.Body.Close()
+
+	if !res.IsError() {
+		if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+			return errors.Wrap(err, "error while json.decode elastic res.Body")
+		}
+
+		for _, template := range r.IndexTemplates {
+			if template.IndexTemplate.Version >= productMappingVersion {
+				return nil
+			}
+		}
+	}
+
+	body, err := json.Marshal(productIndexTemplate())
+	if err != nil {
+		return errors.Wrap(err, "error while marshaling index template")
+	}
+
+	putRes, err := p.db.Indices.PutIndexTemplate(config.ElasticProductIndex, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "error while put index template")
+	}
+	defer putRes.Body.Close()
+
+	if putRes.IsError() {
+		return p.readError(putRes, "error while put products index template on elastic")
+	}
+
+	p.log.Info("products index template installed", logger.Int("version", productMappingVersion))
+
+	return nil
+}
+
+// CheckMapping returns an error if an index behind the alias is older than minProductMappingVersion,
+// a missing index is created from the template on the first write
+func (p *productIndexRepo) CheckMapping(alias string) error {
+
+	var r map[string]struct {
+		Mappings struct {
+			Meta struct {
+				MappingVersion int `json:"mapping_version"`
+			} `json:"_meta"`
+		} `json:"mappings"`
+	}
+
+	res, err := p.db.Indices.GetMapping(p.db.Indices.GetMapping.WithIndex(alias))
+	if err != nil {
+		return errors.Wrap(err, "error while get mapping")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if res.IsError() {
+		return p.readError(res, "error while get products mapping on elastic")
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return errors.Wrap(err, "error while json.decode elastic res.Body")
+	}
+
+	for index, mapping := range r {
+		if mapping.Mappings.Meta.MappingVersion < minProductMappingVersion {
+			return fmt.Errorf("mapping version of %s is %d, at least %d is required, run reindex", index, mapping.Mappings.Meta.MappingVersion, minProductMappingVersion)
+		}
+	}
+
+	return nil
+}
+
+func (p *productIndexRepo) CreateIndex(name string) error {
+
+	if err := p.EnsureTemplate(); err != nil {
+		return err
+	}
+
+	res, err := esapi.IndicesCreateRequest{
+		Index: name,
+	}.Do(context.Background(), p.db)
+	if err != nil {
+		return errors.Wrap(err, "error while create index")
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return p.readError(res, "error while create products index on elastic")
+	}
+
+	return nil
+}
+
+func (p *productIndexRepo) DeleteIndex(name string) error {
+
+	res, err := p.db.Indices.Delete([]string{name})
+	if err != nil {
+		return errors.Wrap(err, "error while delete index")
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return p.readError(res, "error while delete products index on elastic")
+	}
+
+	return nil
+}
+
+func (p *productIndexRepo) BulkIndex(index string, products []*catalog_service.ProductES) error {
+
+	var (
+		buf bytes.Buffer
+		r   struct {
+			Errors bool `json:"errors"`
+			Items  []map[string]struct {
+				Id    string      `json:"_id"`
+				Error interface{} `json:"error"`
+			} `json:"items"`
+		}
+	)
+
+	if len(products) == 0 {
+		return nil
+	}
+
+	for _, product := range products {
+		buf.WriteString(fmt.Sprintf(`{ "index": { "_index": "%s", "_id" : "%s" } }%s`, index, product.Id, "\n"))
+
+		document, err := productDocument(product)
+		if err != nil {
+			return err
+		}
+
+		buf.Write(document)
+		buf.WriteString("\n")
+	}
+
+	res, err := p.db.Bulk(
+		bytes.NewReader(buf.Bytes()),
+		p.db.Bulk.WithIndex(index),
+	)
+	if err != nil {
+		return errors.Wrap(err, "error while bulk index products")
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return p.readError(res, "error while bulk index products on elastic")
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return errors.Wrap(err, "error while json.decode elastic res.Body")
+	}
+
+	if r.Errors {
+		for _, item := range r.Items {
+			for _, result := range item {
+				if result.Error != nil {
+					p.log.Error("error while index product", logger.String("id", result.Id), logger.Any("error", result.Error))
+				}
+			}
+		}
+
+		return errors.New("some products are not indexed on elastic")
+	}
+
+	return nil
+}
+
+func companyQuery(companyId string) H {
+	if companyId == "" {
+		return H{"match_all": H{}}
+	}
+
+	return H{"term": H{"company_id.keyword": companyId}}
+}
+
+func (p *productIndexRepo) Count(index, companyId string) (int, error) {
+
+	var (
+		buf bytes.Buffer
+		r   struct {
+			Count int `json:"count"`
+		}
+	)
+
+	refresh, err := p.db.Indices.Refresh(p.db.Indices.Refresh.WithIndex(index))
+	if err != nil {
+		return 0, errors.Wrap(err, "error while refresh index")
+	}
+	refresh.Body.Close()
+
+	if err := json.NewEncoder(&buf).Encode(H{"query": companyQuery(companyId)}); err != nil {
+		return 0, errors.Wrap(err, "error while encode")
+	}
+
+	res, err := p.db.Count(
+		p.db.Count.WithIndex(index),
+		p.db.Count.WithBody(&buf),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "error while count documents on elastic")
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, p.readError(res, "error while count products on elastic")
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return 0, errors.Wrap(err, "error while json.decode elastic res.Body")
+	}
+
+	return r.Count, nil
+}
+
+func (p *productIndexRepo) GetIds(index, companyId string) ([]string, error) {
+
+	var (
+		ids         = make([]string, 0)
+		searchAfter []interface{}
+	)
+
+	for {
+		var (
+			buf bytes.Buffer
+			r   struct {
+				Hits struct {
+					Hits []struct {
+						Id   string        `json:"_id"`
+						Sort []interface{} `json:"sort"`
+					} `json:"hits"`
+				} `json:"hits"`
+			}
+		)
+
+		searchReq := H{
+			"_source": false,
+			"size":    productIdsPageSize,
+			"query":   companyQuery(companyId),
+			"sort":    []H{{"id.keyword": "asc"}},
+		}
+
+		if searchAfter != nil {
+			searchReq["search_after"] = searchAfter
+		}
+
+		if err := json.NewEncoder(&buf).Encode(searchReq); err != nil {
+			return nil, errors.Wrap(err, "error while encode")
+		}
+
+		res, err := p.db.Search(
+			p.db.Search.WithIndex(index),
+			p.db.Search.WithBody(&buf),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "error while get documents on elastic")
+		}
+
+		if res.IsError() {
+			err = p.readError(res, "error while get product ids on elastic")
+			res.Body.Close()
+			return nil, err
+		}
+
+		err = json.NewDecoder(res.Body).Decode(&r)
+		res.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
+		}
+
+		for _, hit := range r.Hits.Hits {
+			ids = append(ids, hit.Id)
+		}
+
+		if len(r.Hits.Hits) < productIdsPageSize {
+			break
+		}
+
+		searchAfter = r.Hits.Hits[len(r.Hits.Hits)-1].Sort
+	}
+
+	return ids, nil
+}
+
+// GetByIds returns found documents with their sequence numbers for conditional writes
+func (p *productIndexRepo) GetByIds(index string, ids []string) (map[string]*models.ProductDocument, error) {
+
+	var (
+		buf bytes.Buffer
+		res = make(map[string]*models.ProductDocument)
+		r   struct {
+			Docs []struct {
+				Id          string          `json:"_id"`
+				Found       bool            `json:"found"`
+				SeqNo       int64           `json:"_seq_no"`
+				PrimaryTerm int64           `json:"_primary_term"`
+				Source      json.RawMessage `json:"_source"`
+			} `json:"docs"`
+		}
+	)
+
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	if err := json.NewEncoder(&buf).Encode(H{"ids": ids}); err != nil {
+		return nil, errors.Wrap(err, "error while encode")
+	}
+
+	response, err := p.db.Mget(&buf, p.db.Mget.WithIndex(index))
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get documents on elastic")
+	}
+	defer response.Body.Close()
+
+	if response.IsError() {
+		return nil, p.readError(response, "error while get products by ids on elastic")
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&r); err != nil {
+		return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
+	}
+
+	for _, doc := range r.Docs {
+		if !doc.Found {
+			continue
+		}
+
+		var product catalog_service.ProductES
+
+		if err := json.Unmarshal(doc.Source, &product); err != nil {
+			return nil, errors.Wrap(err, "error while json.Unmarshal product")
+		}
+
+		res[doc.Id] = &models.ProductDocument{
+			Product:     &product,
+			SeqNo:       doc.SeqNo,
+			PrimaryTerm: doc.PrimaryTerm,
+		}
+	}
+
+	return res, nil
+}
+
+// BulkIndexIfUnchanged writes products only if their documents were not changed since they were read,
+// products without document are only created. Ids of products changed meanwhile are returned
+func (p *productIndexRepo) BulkIndexIfUnchanged(index string, products []*catalog_service.ProductES, documents map[string]*models.ProductDocument) ([]string, error) {
+
+	var (
+		buf       bytes.Buffer
+		conflicts = make([]string, 0)
+		r         struct {
+			Items []map[string]struct {
+				Id     string      `json:"_id"`
+				Status int         `json:"status"`
+				Error  interface{} `json:"error"`
+			} `json:"items"`
+		}
+	)
+
+	if len(products) == 0 {
+		return conflicts, nil
+	}
+
+	for _, product := range products {
+		if document, ok := documents[product.Id]; ok {
+			buf.WriteString(fmt.Sprintf(`{ "index": { "_index": "%s", "_id" : "%s", "if_seq_no": %d, "if_primary_term": %d } }%s`, index, product.Id, document.SeqNo, document.PrimaryTerm, "\n"))
+		} else {
+			buf.WriteString(fmt.Sprintf(`{ "create": { "_index": "%s", "_id" : "%s" } }%s`, index, product.Id, "\n"))
+		}
+
+		document, err := productDocument(product)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(document)
+		buf.WriteString("\n")
+	}
+
+	res, err := p.db.Bulk(
+		bytes.NewReader(buf.Bytes()),
+		p.db.Bulk.WithIndex(index),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while bulk index products")
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, p.readError(res, "error while bulk index products on elastic")
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
+	}
+
+	failed := false
+
+	for _, item := range r.Items {
+		for _, result := range item {
+			if result.Status == http.StatusConflict {
+				conflicts = append(conflicts, result.Id)
+				continue
+			}
+
+			if result.Error != nil {
+				p.log.Error("error while index product", logger.String("id", result.Id), logger.Any("error", result.Error))
+				failed = true
+			}
+		}
+	}
+
+	if failed {
+		return nil, errors.New("some products are not indexed on elastic")
+	}
+
+	return conflicts, nil
+}
+
+func (p *productIndexRepo) DeleteIds(index string, ids []string) error {
+
+	var (
+		buf bytes.Buffer
+	)
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	for _, id := range ids {
+		buf.WriteString(fmt.Sprintf(`{ "delete": { "_index": "%s", "_id" : "%s" } }%s`, index, id, "\n"))
+	}
+
+	res, err := p.db.Bulk(
+		bytes.NewReader(buf.Bytes()),
+		p.db.Bulk.WithIndex(index),
+		p.db.Bulk.WithRefresh("wait_for"),
+	)
+	if err != nil {
+		return errors.Wrap(err, "error while bulk delete products")
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return p.readError(res, "error while bulk delete products on elastic")
+	}
+
+	return nil
+}
+
+// SwapAlias points alias to index in one request and returns indices it pointed to before.
+// A concrete index with the alias name, left from before the alias was introduced, is removed,
+// with keepOld it is cloned to "<alias>_legacy" first.
+func (p *productIndexRepo) SwapAlias(alias, index string, keepOld bool) ([]string, error) {
+
+	var (
+		buf      bytes.Buffer
+		previous = make(map[string]interface{})
+		oldIndex = make([]string, 0)
+		actions  = make([]H, 0)
+	)
+
+	res, err := p.db.Indices.GetAlias(p.db.Indices.GetAlias.WithName(alias))
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get alias")
+	}
+
+	if res.StatusCode == 404 {
+		res.Body.Close()
+
+		if exists(p.db, alias) {
+			if keepOld {
+				legacy := alias + "_legacy"
+				if err := p.cloneIndex(alias, legacy); err != nil {
+					return nil, err
+				}
+
+				oldIndex = append(oldIndex, legacy)
+			}
+
+			actions = append(actions, H{"remove_index": H{"index": alias}})
+		}
+	} else {
+		defer res.Body.Close()
+
+		if res.IsError() {
+			return nil, p.readError(res, "error while get products alias on elastic")
+		}
+
+		if err := json.NewDecoder(res.Body).Decode(&previous); err != nil {
+			return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
+		}
+	}
+
+	for name := range previous {
+		if name == index {
+			continue
+		}
+
+		oldIndex = append(oldIndex, name)
+		actions = append(actions, H{"remove": H{"index": name, "alias": alias}})
+	}
+
+	actions = append(actions, H{"add": H{"index": index, "alias": alias, "is_write_index": true}})
+
+	if err := json.NewEncoder(&buf).Encode(H{"actions": actions}); err != nil {
+		return nil, errors.Wrap(err, "error while encode")
+	}
+
+	updateRes, err := p.db.Indices.UpdateAliases(&buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while update aliases")
+	}
+	defer updateRes.Body.Close()
+
+	if updateRes.IsError() {
+		return nil, p.readError(updateRes, "error while swap products alias on elastic")
+	}
+
+	p.log.Info("products alias swapped", logger.String("index", index), logger.String("old", strings.Join(oldIndex, ",")))
+
+	return oldIndex, nil
+}
+
+// cloneIndex copies index to target, writes to index are blocked as clone requires
+func (p *productIndexRepo) cloneIndex(index, target string) error {
+
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(H{"index.blocks.write": true}); err != nil {
+		return errors.Wrap(err, "error while encode")
+	}
+
+	res, err := p.db.Indices.PutSettings(&buf, p.db.Indices.PutSettings.WithIndex(index))
+	if err != nil {
+		return errors.Wrap(err, "error while block writes")
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return p.readError(res, "error while block writes on products index")
+	}
+
+	buf.Reset()
+
+	if err := json.NewEncoder(&buf).Encode(H{"settings": H{"index.blocks.write": nil}}); err != nil {
+		return errors.Wrap(err, "error while encode")
+	}
+
+	cloneRes, err := p.db.Indices.Clone(index, target, p.db.Indices.Clone.WithBody(&buf), p.db.Indices.Clone.WithWaitForActiveShards("1"))
+	if err != nil {
+		return errors.Wrap(err, "error while clone index")
+	}
+	defer cloneRes.Body.Close()
+
+	if cloneRes.IsError() {
+		return p.readError(cloneRes, "error while clone products index on elastic")
+	}
+
+	p.log.Info("products index cloned", logger.String("index", index), logger.String("target", target))
+
+	return nil
+}
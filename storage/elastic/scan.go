@@ -0,0 +1,322 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"genproto/catalog_service"
+	"io"
+
+	"github.com/clarketm/json"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/pkg/errors"
+)
+
+const (
+	scanPageSize         = 1000
+	pointInTimeKeepAlive = "5m"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type searchPage struct {
+	PitId string `json:"pit_id"`
+	Hits  struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Source json.RawMessage   `json:"_source"`
+			Sort   []json.RawMessage `json:"sort"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// productsCursor is passed to the client as opaque base64 string
+type productsCursor struct {
+	PitId       string            `json:"pit_id"`
+	SearchAfter []json.RawMessage `json:"search_after"`
+}
+
+func encodeCursor(cursor productsCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", errors.Wrap(err, "error while marshal cursor")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(value string) (productsCursor, error) {
+	var cursor productsCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.PitId == "" {
+		return cursor, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+func (p *productRepo) readError(res *esapi.Response, msg string) error {
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrap(err, "error while reading data")
+	}
+
+	p.log.Error(msg, logger.Any("res", string(data)))
+	return errors.New(msg + " " + string(data))
+}
+
+func (p *productRepo) openPointInTime(ctx context.Context) (string, error) {
+
+	var r struct {
+		Id string `json:"id"`
+	}
+
+	res, err := p.db.OpenPointInTime(
+		[]string{config.ElasticProductIndex},
+		pointInTimeKeepAlive,
+		p.db.OpenPointInTime.WithContext(ctx),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "error while open point in time")
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", p.readError(res, "error while open point in time on elastic")
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return "", errors.Wrap(err, "error while json.decode elastic res.Body")
+	}
+
+	return r.Id, nil
+}
+
+func (p *productRepo) closePointInTime(id string) {
+
+	body, err := json.Marshal(H{"id": id})
+	if err != nil {
+		return
+	}
+
+	res, err := p.db.ClosePointInTime(p.db.ClosePointInTime.WithBody(bytes.NewReader(body)))
+	if err != nil {
+		p.log.Error("error while close point in time", logger.Error(err))
+		return
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		_ = p.readError(res, "error while close point in time on elastic")
+	}
+}
+
+// searchPage searches next page inside point in time, index must not be set when pit is used
+func (p *productRepo) searchPage(ctx context.Context, searchReq H, pitId string, searchAfter []json.RawMessage, size int) (*searchPage, error) {
+
+	var (
+		buf  bytes.Buffer
+		page searchPage
+		body = H{
+			"size": size,
+			"pit": H{
+				"id":         pitId,
+				"keep_alive": pointInTimeKeepAlive,
+			},
+			"track_total_hits": searchAfter == nil,
+		}
+	)
+
+	for key, value := range searchReq {
+		body[key] = value
+	}
+
+	// search_after needs sort values on hits
+	if _, ok := body["sort"]; !ok {
+		body["sort"] = []H{{"_shard_doc": "asc"}}
+	}
+
+	if searchAfter != nil {
+		body["search_after"] = searchAfter
+	}
+
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, errors.Wrap(err, "error while encode")
+	}
+
+	res, err := p.db.Search(
+		p.db.Search.WithContext(ctx),
+		p.db.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get documents on elastic")
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, p.readError(res, "error while get products page on elastic")
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+		return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
+	}
+
+	return &page, nil
+}
+
+func (p *productRepo) pageProducts(page *searchPage) ([]*catalog_service.ProductES, error) {
+
+	products := make([]*catalog_service.ProductES, 0, len(page.Hits.Hits))
+
+	for _, hit := range page.Hits.Hits {
+
+		product := catalog_service.ProductES{}
+
+		if err := json.Unmarshal(hit.Source, &product); err != nil {
+			return nil, errors.Wrap(err, "error while json.Unmarshal jsonString &product")
+		}
+
+		if product.Image != "" {
+			product.Image = fmt.Sprintf("https://%s/%s/%s", p.cfg.MinioEndpoint, config.FileBucketName, product.Image)
+		}
+
+		products = append(products, &product)
+	}
+
+	return products, nil
+}
+
+// scan walks all documents matching searchReq inside one point in time, so the result is not limited by max_result_window.
+// fn gets every page with the total number of documents, scan stops when ctx is done
+func (p *productRepo) scan(ctx context.Context, searchReq H, fn func([]*catalog_service.ProductES, int64) error) error {
+
+	pitId, err := p.openPointInTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		p.closePointInTime(pitId)
+	}()
+
+	var (
+		searchAfter []json.RawMessage
+		total       int64
+	)
+
+	for {
+		page, err := p.searchPage(ctx, searchReq, pitId, searchAfter, scanPageSize)
+		if err != nil {
+			return err
+		}
+
+		// total hits are tracked on the first page only
+		if searchAfter == nil {
+			total = page.Hits.Total.Value
+		}
+
+		if page.PitId != "" {
+			pitId = page.PitId
+		}
+
+		products, err := p.pageProducts(page)
+		if err != nil {
+			return err
+		}
+
+		if len(products) > 0 {
+			if err := fn(products, total); err != nil {
+				return err
+			}
+		}
+
+		if len(page.Hits.Hits) < scanPageSize {
+			return nil
+		}
+
+		searchAfter = page.Hits.Hits[len(page.Hits.Hits)-1].Sort
+	}
+}
+
+func (p *productRepo) ScanAll(ctx context.Context, req *catalog_service.GetAllProductsRequest, fn func([]*catalog_service.ProductES, int64) error) error {
+
+	searchReq, err := makeGettAllSearchRequest(req, false)
+	if err != nil {
+		return err
+	}
+
+	return p.scan(ctx, searchReq, fn)
+}
+
+func (p *productRepo) GetAllByCursor(ctx context.Context, req *catalog_service.GetAllProductsByCursorRequest) (*catalog_service.GetAllProductsByCursorResponse, error) {
+
+	var (
+		res = catalog_service.GetAllProductsByCursorResponse{
+			Data: make([]*catalog_service.ProductES, 0),
+		}
+		cursor productsCursor
+		size   = int(req.Products.GetLimit())
+	)
+
+	if size <= 0 || size > scanPageSize {
+		size = scanPageSize
+	}
+
+	searchReq, err := makeGettAllSearchRequest(req.Products, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != "" {
+		cursor, err = decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		cursor.PitId, err = p.openPointInTime(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	page, err := p.searchPage(ctx, searchReq, cursor.PitId, cursor.SearchAfter, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.PitId != "" {
+		cursor.PitId = page.PitId
+	}
+
+	res.Data, err = p.pageProducts(page)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Total = page.Hits.Total.Value
+
+	if len(page.Hits.Hits) < size {
+		p.closePointInTime(cursor.PitId)
+		return &res, nil
+	}
+
+	cursor.SearchAfter = page.Hits.Hits[len(page.Hits.Hits)-1].Sort
+
+	res.Cursor, err = encodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,77 @@
+package elastic
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/clarketm/json"
+)
+
+func TestCursor(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		cursor productsCursor
+	}{
+		{
+			name:   "first page",
+			cursor: productsCursor{PitId: "pit"},
+		},
+		{
+			name: "search after",
+			cursor: productsCursor{
+				PitId:       "pit-id/with+chars==",
+				SearchAfter: []json.RawMessage{json.RawMessage(`1690000000000`), json.RawMessage(`"p1"`), json.RawMessage(`42`)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := encodeCursor(tt.cursor)
+			if err != nil {
+				t.Fatalf("encodeCursor() error = %v", err)
+			}
+
+			got, err := decodeCursor(value)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+
+			if got.PitId != tt.cursor.PitId {
+				t.Errorf("decodeCursor() pit id = %s, want %s", got.PitId, tt.cursor.PitId)
+			}
+
+			if len(got.SearchAfter) != len(tt.cursor.SearchAfter) {
+				t.Fatalf("decodeCursor() search after = %s, want %s", got.SearchAfter, tt.cursor.SearchAfter)
+			}
+
+			for i := range got.SearchAfter {
+				if string(got.SearchAfter[i]) != string(tt.cursor.SearchAfter[i]) {
+					t.Errorf("decodeCursor() search after %d = %s, want %s", i, got.SearchAfter[i], tt.cursor.SearchAfter[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "not base64", value: "not a cursor!"},
+		{name: "not json", value: base64.RawURLEncoding.EncodeToString([]byte("pit"))},
+		{name: "without pit id", value: base64.RawURLEncoding.EncodeToString([]byte(`{"search_after":[1]}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.value); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
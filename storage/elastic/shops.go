@@ -0,0 +1,154 @@
+package elastic
+
+import (
+	"bytes"
+	"genproto/catalog_service"
+	"sort"
+
+	"github.com/clarketm/json"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/pkg/errors"
+)
+
+// shopEntry is the nested per shop entry of the product document built from
+// measurement_values and shop_prices, filters, sorts and aggregations by shop use it
+type shopEntry struct {
+	ShopId      string  `json:"shop_id"`
+	RetailPrice float32 `json:"retail_price"`
+	SupplyPrice float32 `json:"supply_price"`
+	Amount      float32 `json:"amount"`
+	LowStock    float32 `json:"low_stock"`
+	Available   bool    `json:"available"`
+}
+
+// syncShopsScript rebuilds ctx._source.shops, it must end every script changing
+// measurement_values or shop_prices
+const syncShopsScript = `
+	Map shops = new HashMap();
+	if (ctx._source.measurement_values != null) {
+		for (entry in ctx._source.measurement_values.entrySet()) {
+			def value = entry.getValue();
+			if (value == null) {
+				continue;
+			}
+			Map shop = shops.computeIfAbsent(entry.getKey(), k -> ['shop_id': k, 'retail_price': 0.0, 'supply_price': 0.0, 'amount': 0.0, 'low_stock': 0.0, 'available': false]);
+			shop.amount = value.amount == null ? 0.0 : value.amount;
+			shop.low_stock = value.small_left == null ? 0.0 : value.small_left;
+			shop.available = value.is_available == null ? false : value.is_available;
+		}
+	}
+	if (ctx._source.shop_prices != null) {
+		for (entry in ctx._source.shop_prices.entrySet()) {
+			def value = entry.getValue();
+			if (value == null) {
+				continue;
+			}
+			Map shop = shops.computeIfAbsent(entry.getKey(), k -> ['shop_id': k, 'retail_price': 0.0, 'supply_price': 0.0, 'amount': 0.0, 'low_stock': 0.0, 'available': false]);
+			shop.retail_price = value.retail_price == null ? 0.0 : value.retail_price;
+			shop.supply_price = value.supply_price == null ? 0.0 : value.supply_price;
+		}
+	}
+	ctx._source.shops = new ArrayList(shops.values());
+`
+
+// mergeDocumentScript applies params.doc like a partial update, per shop maps are merged by shop
+const mergeDocumentScript = `
+	for (entry in params.doc.entrySet()) {
+		String key = entry.getKey();
+		if ((key == 'measurement_values' || key == 'shop_prices') && ctx._source[key] != null && entry.getValue() != null) {
+			ctx._source[key].putAll(entry.getValue());
+		} else {
+			ctx._source[key] = entry.getValue();
+		}
+	}
+` + syncShopsScript
+
+func shopEntries(product *catalog_service.ProductES) []*shopEntry {
+
+	var (
+		shops = make(map[string]*shopEntry)
+		res   = make([]*shopEntry, 0)
+	)
+
+	entry := func(shopId string) *shopEntry {
+		if _, ok := shops[shopId]; !ok {
+			shops[shopId] = &shopEntry{ShopId: shopId}
+		}
+
+		return shops[shopId]
+	}
+
+	for shopId, value := range product.MeasurementValues {
+		if value == nil {
+			continue
+		}
+
+		shop := entry(shopId)
+		shop.Amount = value.Amount
+		shop.LowStock = value.SmallLeft
+		shop.Available = value.IsAvailable
+	}
+
+	for shopId, price := range product.ShopPrices {
+		if price == nil {
+			continue
+		}
+
+		shop := entry(shopId)
+		shop.RetailPrice = price.RetailPrice
+		shop.SupplyPrice = price.SupplyPrice
+	}
+
+	for _, shop := range shops {
+		res = append(res, shop)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ShopId < res[j].ShopId
+	})
+
+	return res
+}
+
+// productDocument is the product as it is stored on elastic
+func productDocument(product *catalog_service.ProductES) (json.RawMessage, error) {
+
+	var (
+		buf      bytes.Buffer
+		document map[string]interface{}
+	)
+
+	if err := config.JSONPBMarshaler.Marshal(&buf, product); err != nil {
+		return nil, errors.Wrap(err, "error while marshaling, jsonpb")
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &document); err != nil {
+		return nil, errors.Wrap(err, "error while json.Unmarshal product")
+	}
+
+	document["shops"] = shopEntries(product)
+
+	return json.Marshal(document)
+}
+
+// mergeDocumentBody is the update request body merging product into the stored document,
+// the product is indexed as it is when there is no document yet
+func mergeDocumentBody(product *catalog_service.ProductES) ([]byte, error) {
+
+	document, err := productDocument(product)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(H{
+		"script": H{
+			"source": mergeDocumentScript,
+			"lang":   "painless",
+			"params": H{
+				"doc": document,
+			},
+		},
+		"upsert": document,
+	})
+}
@@ -0,0 +1,98 @@
+package elastic
+
+import (
+	"errors"
+	"genproto/catalog_service"
+	"strings"
+)
+
+type sortFunction func(req *catalog_service.GetAllProductsRequest, order string) (H, error)
+
+var (
+	ErrSortNotFound     = errors.New("sort not found")
+	ErrSortShopRequired = errors.New("shop_ids is required for sorting by shop value")
+
+	sortFunctionMap = map[string]sortFunction{
+		"name":         fieldSort("name.keyword"),
+		"sku":          fieldSort("sku.keyword"),
+		"created_at":   fieldSort("created_at.keyword"),
+		"updated_at":   fieldSort("updated_at"),
+		"retail_price": shopValueSort("retail_price"),
+		"supply_price": shopValueSort("supply_price"),
+		"amount":       shopValueSort("amount"),
+	}
+)
+
+func makeSort(req *catalog_service.GetAllProductsRequest) ([]H, error) {
+
+	sort := make([]H, 0)
+
+	if req.SortBy == "" {
+		sort = append(sort, H{
+			"updated_at": H{
+				"order": "desc",
+			},
+		})
+
+		return sort, nil
+	}
+
+	sortFunction, ok := sortFunctionMap[req.SortBy]
+	if !ok {
+		return nil, ErrSortNotFound
+	}
+
+	order := "asc"
+	if strings.ToLower(req.SortType) == "desc" {
+		order = "desc"
+	}
+
+	field, err := sortFunction(req, order)
+	if err != nil {
+		return nil, err
+	}
+
+	sort = append(sort, field, H{
+		"id.keyword": H{
+			"order": "asc",
+		},
+	})
+
+	return sort, nil
+}
+
+func fieldSort(field string) sortFunction {
+	return func(req *catalog_service.GetAllProductsRequest, order string) (H, error) {
+		return H{
+			field: H{
+				"order":   order,
+				"missing": "_last",
+			},
+		}, nil
+	}
+}
+
+// shopValueSort sorts by value of the first requested shop, products without the value go last
+func shopValueSort(field string) sortFunction {
+	return func(req *catalog_service.GetAllProductsRequest, order string) (H, error) {
+
+		if len(req.ShopIds) == 0 {
+			return nil, ErrSortShopRequired
+		}
+
+		return H{
+			"shops." + field: H{
+				"order":   order,
+				"missing": "_last",
+				"nested": H{
+					"path": "shops",
+					"filter": H{
+						"term": H{
+							"shops.shop_id": req.ShopIds[0],
+						},
+					},
+				},
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,292 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"genproto/catalog_service"
+	"io"
+
+	"github.com/clarketm/json"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+const valuationBucketsSize = 1000
+
+type docCount struct {
+	DocCount int64 `json:"doc_count"`
+}
+
+// valuationAggregation is the result of valuationAggs, stock counts come from productStockAggs or shopStockAggs
+type valuationAggregation struct {
+	TotalRetailPrice struct {
+		Value float64 `json:"value"`
+	} `json:"total_retail_price"`
+	TotalSupplyPrice struct {
+		Value float64 `json:"value"`
+	} `json:"total_supply_price"`
+}
+
+type productStockAggregation struct {
+	OutOfStock docCount `json:"out_of_stock"`
+	LowStock   docCount `json:"low_stock"`
+}
+
+type shopStockAggregation struct {
+	OutOfStock struct {
+		Products docCount `json:"products"`
+	} `json:"out_of_stock"`
+	LowStock struct {
+		Products docCount `json:"products"`
+	} `json:"low_stock"`
+}
+
+func (v valuationAggregation) valuation(outOfStock, lowStock docCount) *catalog_service.Valuation {
+	return &catalog_service.Valuation{
+		TotalRetailPrice: v.TotalRetailPrice.Value,
+		TotalSupplyPrice: v.TotalSupplyPrice.Value,
+		OutOfStock:       outOfStock.DocCount,
+		LowStock:         lowStock.DocCount,
+	}
+}
+
+// valuationAggs sums stock value of nested shops
+func valuationAggs() H {
+	return H{
+		"total_retail_price": H{
+			"sum": H{
+				"script": H{
+					"lang":   "painless",
+					"source": "doc['shops.amount'].value * doc['shops.retail_price'].value",
+				},
+			},
+		},
+		"total_supply_price": H{
+			"sum": H{
+				"script": H{
+					"lang":   "painless",
+					"source": "doc['shops.amount'].value * doc['shops.supply_price'].value",
+				},
+			},
+		},
+	}
+}
+
+// productStockAggs counts products by the rules of out_of_stock and low_stock filters
+func productStockAggs(shopIds []string) H {
+	return H{
+		"out_of_stock": H{
+			"filter": outOfStockQuery(shopIds, true),
+		},
+		"low_stock": H{
+			"filter": lowStockQuery(shopIds, true),
+		},
+	}
+}
+
+// shopStockAggs counts products of a nested shop bucket, a product has one entry of the shop
+// so it is out of stock when the entry has no stock
+func shopStockAggs() H {
+	products := H{
+		"products": H{
+			"reverse_nested": H{},
+		},
+	}
+
+	return H{
+		"out_of_stock": H{
+			"filter": H{
+				"bool": H{
+					"must_not": []H{{"range": H{"shops.amount": H{"gt": 0}}}},
+				},
+			},
+			"aggs": products,
+		},
+		"low_stock": H{
+			"filter": lowStockScript(),
+			"aggs":   products,
+		},
+	}
+}
+
+// shopsAggs aggregates nested shop entries of the shops, all shops if shopIds is empty
+func shopsAggs(shopIds []string, aggs H) H {
+
+	filter := H{"match_all": H{}}
+	if len(shopIds) > 0 {
+		filter = H{"terms": H{"shops.shop_id": shopIds}}
+	}
+
+	return H{
+		"nested": H{
+			"path": "shops",
+		},
+		"aggs": H{
+			"selected": H{
+				"filter": filter,
+				"aggs":   aggs,
+			},
+		},
+	}
+}
+
+// valuationResponse is the result of valuationRequestAggs
+type valuationResponse struct {
+	Total struct {
+		Selected valuationAggregation `json:"selected"`
+	} `json:"total"`
+	productStockAggregation
+	Shops struct {
+		Selected struct {
+			ByShop struct {
+				Buckets []struct {
+					Key string `json:"key"`
+					valuationAggregation
+					shopStockAggregation
+				} `json:"buckets"`
+			} `json:"by_shop"`
+		} `json:"selected"`
+	} `json:"shops"`
+	Categories struct {
+		Buckets []struct {
+			Key   string `json:"key"`
+			Shops struct {
+				Selected valuationAggregation `json:"selected"`
+			} `json:"shops"`
+			productStockAggregation
+		} `json:"buckets"`
+	} `json:"categories"`
+}
+
+func (r *valuationResponse) response() *catalog_service.GetValuationResponse {
+
+	var res = catalog_service.GetValuationResponse{
+		Total:      r.Total.Selected.valuation(r.OutOfStock, r.LowStock),
+		Shops:      make([]*catalog_service.ShopValuation, 0, len(r.Shops.Selected.ByShop.Buckets)),
+		Categories: make([]*catalog_service.CategoryValuation, 0, len(r.Categories.Buckets)),
+	}
+
+	for _, bucket := range r.Shops.Selected.ByShop.Buckets {
+		res.Shops = append(res.Shops, &catalog_service.ShopValuation{
+			ShopId:    bucket.Key,
+			Valuation: bucket.valuation(bucket.OutOfStock.Products, bucket.LowStock.Products),
+		})
+	}
+
+	for _, bucket := range r.Categories.Buckets {
+		res.Categories = append(res.Categories, &catalog_service.CategoryValuation{
+			CategoryId: bucket.Key,
+			Valuation:  bucket.Shops.Selected.valuation(bucket.OutOfStock, bucket.LowStock),
+		})
+	}
+
+	return &res
+}
+
+// valuationRequestAggs aggregates valuation of all products, each shop and each category.
+// Stock value is summed over nested shops, out of stock and low stock count products
+func valuationRequestAggs(shopIds []string) H {
+
+	aggs := productStockAggs(shopIds)
+
+	aggs["total"] = shopsAggs(shopIds, valuationAggs())
+
+	shopAggs := valuationAggs()
+	for key, value := range shopStockAggs() {
+		shopAggs[key] = value
+	}
+
+	aggs["shops"] = shopsAggs(shopIds, H{
+		"by_shop": H{
+			"terms": H{
+				"field": "shops.shop_id",
+				"size":  valuationBucketsSize,
+			},
+			"aggs": shopAggs,
+		},
+	})
+
+	categoryAggs := productStockAggs(shopIds)
+	categoryAggs["shops"] = shopsAggs(shopIds, valuationAggs())
+
+	aggs["categories"] = H{
+		"terms": H{
+			"field": "categories.id.keyword",
+			"size":  valuationBucketsSize,
+		},
+		"aggs": categoryAggs,
+	}
+
+	return aggs
+}
+
+func (p *productRepo) GetValuation(req *catalog_service.GetValuationRequest) (*catalog_service.GetValuationResponse, error) {
+
+	var (
+		buf    bytes.Buffer
+		filter = []H{
+			{
+				"term": H{
+					"company_id.keyword": req.Request.CompanyId,
+				},
+			},
+		}
+		r struct {
+			Aggregations valuationResponse `json:"aggregations"`
+		}
+	)
+
+	if len(req.CategoryIds) > 0 {
+		filter = append(filter, H{
+			"terms": H{
+				"categories.id.keyword": req.CategoryIds,
+			},
+		})
+	}
+
+	if len(req.ShopIds) > 0 {
+		filter = append(filter, shopsQuery(req.ShopIds))
+	}
+
+	searchReq := H{
+		"size": 0,
+		"query": H{
+			"bool": H{
+				"filter": filter,
+			},
+		},
+		"aggs": valuationRequestAggs(req.ShopIds),
+	}
+
+	if err := json.NewEncoder(&buf).Encode(searchReq); err != nil {
+		return nil, errors.Wrap(err, "error while encode")
+	}
+
+	response, err := p.db.Search(
+		p.db.Search.WithContext(context.Background()),
+		p.db.Search.WithIndex(config.ElasticProductIndex),
+		p.db.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get valuation on elastic")
+	}
+	defer response.Body.Close()
+
+	if response.IsError() {
+		data, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		p.log.Error("errror while get valuation ", logger.Any("res", string(data)))
+		return nil, errors.New("error while get valuation on elastic " + string(data))
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&r); err != nil {
+		return nil, errors.Wrap(err, "error while json.decode elastic res.Body")
+	}
+
+	return r.Aggregations.response(), nil
+}
@@ -0,0 +1,116 @@
+package elastic
+
+import (
+	"encoding/json"
+	"genproto/catalog_service"
+	"genproto/common"
+	"testing"
+)
+
+func TestValuationStockCountsMatchFilters(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		shopIds []string
+		filters map[string]string
+	}{
+		{
+			name:    "selected shops",
+			shopIds: []string{"s1", "s2"},
+			filters: map[string]string{"out_of_stock": "s1,s2:true", "low_stock": "s1,s2:true"},
+		},
+		{
+			name:    "one shop",
+			shopIds: []string{"s1"},
+			filters: map[string]string{"out_of_stock": "s1:true", "low_stock": "s1:true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggs := valuationRequestAggs(tt.shopIds)
+
+			for key, value := range tt.filters {
+				clauses, err := makeFilterClauses(&catalog_service.GetAllProductsRequest{
+					Filters: []*common.FilterField{{Key: key, Relation: common.Relation_EQUAL, Value: value}},
+				})
+				if err != nil {
+					t.Fatalf("makeFilterClauses() error = %v", err)
+				}
+
+				want, err := json.Marshal(clauses[0].query)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := json.Marshal(aggs[key].(H)["filter"])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(got) != string(want) {
+					t.Errorf("%s aggregation filter = %s, want %s", key, got, want)
+				}
+
+				category, err := json.Marshal(aggs["categories"].(H)["aggs"].(H)[key].(H)["filter"])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(category) != string(want) {
+					t.Errorf("category %s aggregation filter = %s, want %s", key, category, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValuationResponse(t *testing.T) {
+
+	data := `{
+		"total": {"selected": {"total_retail_price": {"value": 300}, "total_supply_price": {"value": 200}}},
+		"out_of_stock": {"doc_count": 2},
+		"low_stock": {"doc_count": 1},
+		"shops": {"selected": {"by_shop": {"buckets": [{
+			"key": "s1",
+			"doc_count": 5,
+			"total_retail_price": {"value": 100},
+			"total_supply_price": {"value": 50},
+			"out_of_stock": {"doc_count": 4, "products": {"doc_count": 3}},
+			"low_stock": {"doc_count": 1, "products": {"doc_count": 1}}
+		}]}}},
+		"categories": {"buckets": [{
+			"key": "c1",
+			"shops": {"selected": {"total_retail_price": {"value": 10}, "total_supply_price": {"value": 5}}},
+			"out_of_stock": {"doc_count": 1},
+			"low_stock": {"doc_count": 0}
+		}]}
+	}`
+
+	var r valuationResponse
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	res := r.response()
+
+	if got := res.Total; got.OutOfStock != 2 || got.LowStock != 1 || got.TotalRetailPrice != 300 || got.TotalSupplyPrice != 200 {
+		t.Errorf("total = %v", got)
+	}
+
+	if len(res.Shops) != 1 || res.Shops[0].ShopId != "s1" {
+		t.Fatalf("shops = %v", res.Shops)
+	}
+
+	if got := res.Shops[0].Valuation; got.OutOfStock != 3 || got.LowStock != 1 || got.TotalRetailPrice != 100 {
+		t.Errorf("shop valuation = %v, want products counted", got)
+	}
+
+	if len(res.Categories) != 1 || res.Categories[0].CategoryId != "c1" {
+		t.Fatalf("categories = %v", res.Categories)
+	}
+
+	if got := res.Categories[0].Valuation; got.OutOfStock != 1 || got.LowStock != 0 || got.TotalSupplyPrice != 5 {
+		t.Errorf("category valuation = %v", got)
+	}
+}
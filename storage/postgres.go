@@ -23,6 +23,12 @@ type repos struct {
 	scalesTemplateRepo  repo.ScalesTemplateI
 	supplierRepo        repo.SupplierI
 	vatRepo             repo.VatI
+	stockMovementRepo   repo.StockMovementI
+	processedEventRepo  repo.ProcessedEventI
+	deadLetterRepo      repo.DeadLetterI
+	outboxRepo          repo.OutboxI
+	jobRepo             repo.JobI
+	importProfileRepo   repo.ImportMappingProfileI
 }
 
 type repoIs interface {
@@ -36,6 +42,12 @@ type repoIs interface {
 	ScalesTemplate() repo.ScalesTemplateI
 	Supplier() repo.SupplierI
 	Vat() repo.VatI
+	StockMovement() repo.StockMovementI
+	ProcessedEvent() repo.ProcessedEventI
+	DeadLetter() repo.DeadLetterI
+	Outbox() repo.OutboxI
+	Job() repo.JobI
+	ImportMappingProfile() repo.ImportMappingProfileI
 }
 
 type storage struct {
@@ -96,6 +108,12 @@ func getRepos(log logger.Logger, db models.DB, cfg config.Config) repos {
 		scalesTemplateRepo:  postgres.NewScalesTemplateRepo(log, db, cfg),
 		supplierRepo:        postgres.NewSupplierRepo(log, db, cfg),
 		vatRepo:             postgres.NewVatRepo(log, db, cfg),
+		stockMovementRepo:   postgres.NewStockMovementRepo(log, db),
+		processedEventRepo:  postgres.NewProcessedEventRepo(log, db),
+		deadLetterRepo:      postgres.NewDeadLetterRepo(log, db),
+		outboxRepo:          postgres.NewOutboxRepo(log, db),
+		jobRepo:             postgres.NewJobRepo(log, db),
+		importProfileRepo:   postgres.NewImportMappingProfileRepo(log, db),
 	}
 }
 
@@ -145,3 +163,27 @@ func (r *repos) Supplier() repo.SupplierI {
 func (r *repos) Vat() repo.VatI {
 	return r.vatRepo
 }
+
+func (r *repos) StockMovement() repo.StockMovementI {
+	return r.stockMovementRepo
+}
+
+func (r *repos) ProcessedEvent() repo.ProcessedEventI {
+	return r.processedEventRepo
+}
+
+func (r *repos) DeadLetter() repo.DeadLetterI {
+	return r.deadLetterRepo
+}
+
+func (r *repos) Outbox() repo.OutboxI {
+	return r.outboxRepo
+}
+
+func (r *repos) Job() repo.JobI {
+	return r.jobRepo
+}
+
+func (r *repos) ImportMappingProfile() repo.ImportMappingProfileI {
+	return r.importProfileRepo
+}
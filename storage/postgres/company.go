@@ -85,3 +85,26 @@ func (c *companyRepo) Delete(req *common.RequestID) (*common.ResponseID, error)
 
 	return &common.ResponseID{Id: req.Id}, nil
 }
+
+func (c *companyRepo) GetIds() ([]string, error) {
+
+	var ids = make([]string, 0)
+
+	rows, err := c.db.Query(`SELECT id FROM "company" WHERE deleted_at = 0 ORDER BY id`)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get companies")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, "error while scanning company id")
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
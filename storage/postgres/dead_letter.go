@@ -0,0 +1,264 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/Invan2/invan_catalog_service/storage/repo"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type deadLetterRepo struct {
+	db  models.DB
+	log logger.Logger
+}
+
+func NewDeadLetterRepo(log logger.Logger, db models.DB) repo.DeadLetterI {
+	return &deadLetterRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (d *deadLetterRepo) Create(letter *models.DeadLetter) error {
+
+	if letter.Id == "" {
+		letter.Id = uuid.NewString()
+	}
+
+	headers, err := json.Marshal(letter.Headers)
+	if err != nil {
+		return errors.Wrap(err, "error while marshal headers")
+	}
+
+	query := `
+		INSERT INTO
+			"dead_letter"
+		(
+			id,
+			topic,
+			partition,
+			"offset",
+			key,
+			value,
+			headers,
+			error,
+			attempts
+		)
+		VALUES (
+			$1,
+			$2,
+			$3,
+			$4,
+			$5,
+			$6,
+			$7,
+			$8,
+			$9
+		)
+	`
+
+	_, err = d.db.Exec(
+		query,
+		letter.Id,
+		letter.Topic,
+		letter.Partition,
+		letter.Offset,
+		letter.Key,
+		letter.Value,
+		headers,
+		letter.Error,
+		letter.Attempts,
+	)
+	if err != nil {
+		return errors.Wrap(err, "error while insert dead_letter")
+	}
+
+	return nil
+}
+
+func (d *deadLetterRepo) GetAll(req *models.GetDeadLettersRequest) (*models.GetDeadLettersResponse, error) {
+
+	var (
+		res = models.GetDeadLettersResponse{
+			Data: make([]*models.DeadLetter, 0),
+		}
+		values = map[string]interface{}{
+			"limit":  req.Limit,
+			"offset": req.Limit * (req.Page - 1),
+			"topic":  req.Topic,
+		}
+	)
+
+	query := `
+		SELECT
+			id,
+			topic,
+			partition,
+			"offset",
+			key,
+			value,
+			headers,
+			error,
+			attempts,
+			created_at,
+			replayed_at
+		FROM "dead_letter"
+	`
+
+	filter := ` WHERE TRUE `
+	if req.Topic != "" {
+		filter += ` AND topic = :topic `
+	}
+
+	if req.NotReplayed {
+		filter += ` AND replayed_at IS NULL `
+	}
+
+	query += filter + `
+		ORDER BY created_at DESC
+		LIMIT :limit
+		OFFSET :offset
+	`
+
+	rows, err := d.db.NamedQuery(query, values)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get dead letters")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Data = append(res.Data, letter)
+	}
+
+	query = `
+		SELECT
+			count(id)
+		FROM "dead_letter"
+	` + filter
+
+	stmt, err := d.db.PrepareNamed(query)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while prepareName")
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(values).Scan(&res.Total)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while scanning queryRow")
+	}
+
+	return &res, nil
+}
+
+func (d *deadLetterRepo) GetByIds(ids []string) ([]*models.DeadLetter, error) {
+
+	var res = make([]*models.DeadLetter, 0)
+
+	query := `
+		SELECT
+			id,
+			topic,
+			partition,
+			"offset",
+			key,
+			value,
+			headers,
+			error,
+			attempts,
+			created_at,
+			replayed_at
+		FROM "dead_letter"
+		WHERE id = ANY($1)
+		ORDER BY created_at
+	`
+
+	rows, err := d.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get dead letters by ids")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, letter)
+	}
+
+	return res, nil
+}
+
+func (d *deadLetterRepo) MarkReplayed(id string) error {
+
+	query := `
+		UPDATE
+			"dead_letter"
+		SET
+			replayed_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	_, err := d.db.Exec(query, id)
+	if err != nil {
+		return errors.Wrap(err, "error while mark dead letter replayed")
+	}
+
+	return nil
+}
+
+func scanDeadLetter(rows interface{ Scan(...interface{}) error }) (*models.DeadLetter, error) {
+
+	var (
+		letter     models.DeadLetter
+		headers    []byte
+		createdAt  sql.NullTime
+		replayedAt sql.NullTime
+	)
+
+	err := rows.Scan(
+		&letter.Id,
+		&letter.Topic,
+		&letter.Partition,
+		&letter.Offset,
+		&letter.Key,
+		&letter.Value,
+		&headers,
+		&letter.Error,
+		&letter.Attempts,
+		&createdAt,
+		&replayedAt,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while scanning dead letter")
+	}
+
+	if err := json.Unmarshal(headers, &letter.Headers); err != nil {
+		return nil, errors.Wrap(err, "error while unmarshal headers")
+	}
+
+	if createdAt.Valid {
+		letter.CreatedAt = createdAt.Time.Format(config.DateTimeFormat)
+	}
+
+	if replayedAt.Valid {
+		letter.ReplayedAt = replayedAt.Time.Format(config.DateTimeFormat)
+	}
+
+	return &letter, nil
+}
@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/Invan2/invan_catalog_service/storage/repo"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const importMappingProfileColumns = `
+	id,
+	company_id,
+	name,
+	mapping,
+	encoding,
+	delimiter,
+	decimal_separator,
+	COALESCE(CAST(created_by AS VARCHAR), ''),
+	created_at,
+	updated_at
+`
+
+type importMappingProfileRepo struct {
+	db  models.DB
+	log logger.Logger
+}
+
+func NewImportMappingProfileRepo(log logger.Logger, db models.DB) repo.ImportMappingProfileI {
+	return &importMappingProfileRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (i *importMappingProfileRepo) Create(profile *models.ImportMappingProfile) error {
+
+	if profile.Id == "" {
+		profile.Id = uuid.NewString()
+	}
+
+	mapping, err := json.Marshal(profile.Mapping)
+	if err != nil {
+		return errors.Wrap(err, "error while marshal mapping")
+	}
+
+	query := `
+		INSERT INTO
+			"import_mapping_profile"
+		(
+			id,
+			company_id,
+			name,
+			mapping,
+			encoding,
+			delimiter,
+			decimal_separator,
+			created_by
+		)
+		VALUES (
+			$1,
+			$2,
+			$3,
+			$4,
+			$5,
+			$6,
+			$7,
+			NULLIF($8, '')::UUID
+		)
+	`
+
+	_, err = i.db.Exec(
+		query,
+		profile.Id,
+		profile.CompanyId,
+		profile.Name,
+		mapping,
+		profile.Encoding,
+		profile.Delimiter,
+		profile.DecimalSeparator,
+		profile.CreatedBy,
+	)
+	if err != nil {
+		return errors.Wrap(err, "error while insert import mapping profile")
+	}
+
+	return nil
+}
+
+func (i *importMappingProfileRepo) Update(profile *models.ImportMappingProfile) error {
+
+	mapping, err := json.Marshal(profile.Mapping)
+	if err != nil {
+		return errors.Wrap(err, "error while marshal mapping")
+	}
+
+	query := `
+		UPDATE
+			"import_mapping_profile"
+		SET
+			name = $3,
+			mapping = $4,
+			encoding = $5,
+			delimiter = $6,
+			decimal_separator = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND company_id = $2 AND deleted_at = 0
+	`
+
+	res, err := i.db.Exec(
+		query,
+		profile.Id,
+		profile.CompanyId,
+		profile.Name,
+		mapping,
+		profile.Encoding,
+		profile.Delimiter,
+		profile.DecimalSeparator,
+	)
+	if err != nil {
+		return errors.Wrap(err, "error while update import mapping profile")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (i *importMappingProfileRepo) GetById(id, companyId string) (*models.ImportMappingProfile, error) {
+
+	query := `
+		SELECT ` + importMappingProfileColumns + `
+		FROM "import_mapping_profile"
+		WHERE id = $1 AND company_id = $2 AND deleted_at = 0
+	`
+
+	return scanImportMappingProfile(i.db.QueryRow(query, id, companyId))
+}
+
+func (i *importMappingProfileRepo) GetAll(companyId string) ([]*models.ImportMappingProfile, error) {
+
+	var res = make([]*models.ImportMappingProfile, 0)
+
+	query := `
+		SELECT ` + importMappingProfileColumns + `
+		FROM "import_mapping_profile"
+		WHERE company_id = $1 AND deleted_at = 0
+		ORDER BY name
+	`
+
+	rows, err := i.db.Query(query, companyId)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get import mapping profiles")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		profile, err := scanImportMappingProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, profile)
+	}
+
+	return res, nil
+}
+
+func (i *importMappingProfileRepo) Delete(id, companyId string) error {
+
+	query := `
+		UPDATE
+			"import_mapping_profile"
+		SET
+			deleted_at = extract(epoch from now())::bigint
+		WHERE id = $1 AND company_id = $2 AND deleted_at = 0
+	`
+
+	res, err := i.db.Exec(query, id, companyId)
+	if err != nil {
+		return errors.Wrap(err, "error while delete import mapping profile")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func scanImportMappingProfile(rows interface{ Scan(...interface{}) error }) (*models.ImportMappingProfile, error) {
+
+	var (
+		profile   models.ImportMappingProfile
+		mapping   []byte
+		createdAt sql.NullTime
+		updatedAt sql.NullTime
+	)
+
+	err := rows.Scan(
+		&profile.Id,
+		&profile.CompanyId,
+		&profile.Name,
+		&mapping,
+		&profile.Encoding,
+		&profile.Delimiter,
+		&profile.DecimalSeparator,
+		&profile.CreatedBy,
+		&createdAt,
+		&updatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error while scanning import mapping profile")
+	}
+
+	if err := json.Unmarshal(mapping, &profile.Mapping); err != nil {
+		return nil, errors.Wrap(err, "error while unmarshal mapping")
+	}
+
+	if createdAt.Valid {
+		profile.CreatedAt = createdAt.Time.Format(config.DateTimeFormat)
+	}
+
+	if updatedAt.Valid {
+		profile.UpdatedAt = updatedAt.Time.Format(config.DateTimeFormat)
+	}
+
+	return &profile, nil
+}
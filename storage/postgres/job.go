@@ -0,0 +1,304 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/Invan2/invan_catalog_service/storage/repo"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const jobColumns = `
+	id,
+	company_id,
+	type,
+	status,
+	payload,
+	progress,
+	result_url,
+	error,
+	cancel_requested,
+	COALESCE(CAST(created_by AS VARCHAR), ''),
+	created_at,
+	started_at,
+	finished_at
+`
+
+type jobRepo struct {
+	db  models.DB
+	log logger.Logger
+}
+
+func NewJobRepo(log logger.Logger, db models.DB) repo.JobI {
+	return &jobRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (j *jobRepo) Create(job *models.Job) error {
+
+	if job.Id == "" {
+		job.Id = uuid.NewString()
+	}
+
+	query := `
+		INSERT INTO
+			"job"
+		(
+			id,
+			company_id,
+			type,
+			status,
+			payload,
+			created_by
+		)
+		VALUES (
+			$1,
+			$2,
+			$3,
+			$4,
+			$5,
+			NULLIF($6, '')::UUID
+		)
+	`
+
+	_, err := j.db.Exec(
+		query,
+		job.Id,
+		job.CompanyId,
+		job.Type,
+		config.JobPending,
+		job.Payload,
+		job.CreatedBy,
+	)
+	if err != nil {
+		return errors.Wrap(err, "error while insert job")
+	}
+
+	job.Status = config.JobPending
+
+	return nil
+}
+
+func (j *jobRepo) GetById(id, companyId string) (*models.Job, error) {
+
+	query := `
+		SELECT ` + jobColumns + `
+		FROM "job"
+		WHERE id = $1 AND company_id = $2
+	`
+
+	return scanJob(j.db.QueryRow(query, id, companyId))
+}
+
+func (j *jobRepo) GetAll(req *models.GetJobsRequest) (*models.GetJobsResponse, error) {
+
+	var (
+		res = models.GetJobsResponse{
+			Data: make([]*models.Job, 0),
+		}
+		values = map[string]interface{}{
+			"company_id": req.CompanyId,
+			"type":       req.Type,
+			"status":     req.Status,
+			"limit":      req.Limit,
+			"offset":     req.Limit * (req.Page - 1),
+		}
+	)
+
+	query := `
+		SELECT ` + jobColumns + `
+		FROM "job"
+	`
+
+	filter := ` WHERE company_id = :company_id `
+	if req.Type != "" {
+		filter += ` AND type = :type `
+	}
+
+	if req.Status != "" {
+		filter += ` AND status = :status `
+	}
+
+	query += filter + `
+		ORDER BY created_at DESC
+		LIMIT :limit
+		OFFSET :offset
+	`
+
+	rows, err := j.db.NamedQuery(query, values)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get jobs")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Data = append(res.Data, job)
+	}
+
+	query = `
+		SELECT
+			count(id)
+		FROM "job"
+	` + filter
+
+	stmt, err := j.db.PrepareNamed(query)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while prepareName")
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(values).Scan(&res.Total)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while scanning queryRow")
+	}
+
+	return &res, nil
+}
+
+func (j *jobRepo) Claim(staleTimeout time.Duration) (*models.Job, error) {
+
+	query := `
+		UPDATE
+			"job"
+		SET
+			status = $1,
+			started_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT
+				id
+			FROM "job"
+			WHERE status = $2 OR (status = $1 AND updated_at < $3)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(j.db.QueryRow(query, config.JobRunning, config.JobPending, time.Now().Add(-staleTimeout)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	return job, err
+}
+
+func (j *jobRepo) UpdateProgress(id string, progress int) (bool, error) {
+
+	var cancelRequested bool
+
+	query := `
+		UPDATE
+			"job"
+		SET
+			progress = GREATEST(progress, $2),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING cancel_requested
+	`
+
+	err := j.db.QueryRow(query, id, progress).Scan(&cancelRequested)
+	if err != nil {
+		return false, errors.Wrap(err, "error while update job progress")
+	}
+
+	return cancelRequested, nil
+}
+
+func (j *jobRepo) Finish(id, status, resultUrl, errMessage string) error {
+
+	query := `
+		UPDATE
+			"job"
+		SET
+			status = $2,
+			result_url = $3,
+			error = $4,
+			progress = CASE WHEN $2 = $5 THEN 100 ELSE progress END,
+			updated_at = CURRENT_TIMESTAMP,
+			finished_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	_, err := j.db.Exec(query, id, status, resultUrl, errMessage, config.JobSucceeded)
+	if err != nil {
+		return errors.Wrap(err, "error while finish job")
+	}
+
+	return nil
+}
+
+func (j *jobRepo) Cancel(id, companyId string) (*models.Job, error) {
+
+	query := `
+		UPDATE
+			"job"
+		SET
+			cancel_requested = TRUE,
+			status = CASE WHEN status = $3 THEN $4 ELSE status END,
+			finished_at = CASE WHEN status = $3 THEN CURRENT_TIMESTAMP ELSE finished_at END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND company_id = $2
+		RETURNING ` + jobColumns
+
+	return scanJob(j.db.QueryRow(query, id, companyId, config.JobPending, config.JobCancelled))
+}
+
+func scanJob(rows interface{ Scan(...interface{}) error }) (*models.Job, error) {
+
+	var (
+		job        models.Job
+		createdAt  sql.NullTime
+		startedAt  sql.NullTime
+		finishedAt sql.NullTime
+	)
+
+	err := rows.Scan(
+		&job.Id,
+		&job.CompanyId,
+		&job.Type,
+		&job.Status,
+		&job.Payload,
+		&job.Progress,
+		&job.ResultUrl,
+		&job.Error,
+		&job.CancelRequested,
+		&job.CreatedBy,
+		&createdAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error while scanning job")
+	}
+
+	if createdAt.Valid {
+		job.CreatedAt = createdAt.Time.Format(config.DateTimeFormat)
+	}
+
+	if startedAt.Valid {
+		job.StartedAt = startedAt.Time.Format(config.DateTimeFormat)
+	}
+
+	if finishedAt.Valid {
+		job.FinishedAt = finishedAt.Time.Format(config.DateTimeFormat)
+	}
+
+	return &job, nil
+}
@@ -253,6 +253,7 @@ func (p *measurementUnitRepo) GetAll(req *catalog_service.GetAllMeasurementUnits
 	}
 
 	namedQuery += filter + `
+		ORDER BY mu.created_at, mu.id
 		LIMIT :limit
 		OFFSET :offset
 	`
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/helper"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/Invan2/invan_catalog_service/storage/repo"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const (
+	// outboxLockID is the key of advisory lock taken by the outbox relay
+	outboxLockID = 7340001
+	// outboxKeyLockID is the class of advisory locks taken per message key by writers
+	outboxKeyLockID = 7340002
+)
+
+type outboxRepo struct {
+	db  models.DB
+	log logger.Logger
+}
+
+func NewOutboxRepo(log logger.Logger, db models.DB) repo.OutboxI {
+	return &outboxRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create must be called in transaction. It holds the lock of the key till the end of transaction,
+// so that messages with the same key get ids in commit order and the relay never sends them out of order
+func (o *outboxRepo) Create(topic string, key string, payload interface{}) (int64, error) {
+
+	var id int64
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, errors.Wrap(err, "error while marshal outbox payload")
+	}
+
+	_, err = o.db.Exec(`SELECT pg_advisory_xact_lock($1, hashtext($2))`, outboxKeyLockID, key)
+	if err != nil {
+		return 0, errors.Wrap(err, "error while lock outbox key")
+	}
+
+	query := `
+		INSERT INTO
+			"outbox"
+		(
+			event_id,
+			topic,
+			key,
+			payload,
+			schema_version
+		)
+		VALUES (
+			$1,
+			$2,
+			$3,
+			$4,
+			$5
+		) RETURNING id
+	`
+
+	err = o.db.QueryRow(query, uuid.NewString(), topic, key, data, helper.EventSchemaVersion).Scan(&id)
+	if err != nil {
+		return 0, errors.Wrap(err, "error while insert outbox")
+	}
+
+	return id, nil
+}
+
+func (o *outboxRepo) Lock() (bool, error) {
+
+	var locked bool
+
+	err := o.db.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, outboxLockID).Scan(&locked)
+	if err != nil {
+		return false, errors.Wrap(err, "error while lock outbox")
+	}
+
+	return locked, nil
+}
+
+func (o *outboxRepo) GetNotSent(limit int) ([]*models.OutboxMessage, error) {
+
+	var res = make([]*models.OutboxMessage, 0)
+
+	query := `
+		SELECT
+			id,
+			event_id,
+			topic,
+			key,
+			payload,
+			schema_version,
+			created_at
+		FROM "outbox"
+		WHERE sent_at IS NULL
+		ORDER BY id
+		LIMIT $1
+	`
+
+	rows, err := o.db.Query(query, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get outbox messages")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var message models.OutboxMessage
+
+		err = rows.Scan(
+			&message.Id,
+			&message.EventId,
+			&message.Topic,
+			&message.Key,
+			&message.Payload,
+			&message.SchemaVersion,
+			&message.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "error while scanning outbox message")
+		}
+
+		res = append(res, &message)
+	}
+
+	return res, nil
+}
+
+func (o *outboxRepo) MarkSent(ids []int64) error {
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE
+			"outbox"
+		SET
+			sent_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($1)
+	`
+
+	_, err := o.db.Exec(query, pq.Array(ids))
+	if err != nil {
+		return errors.Wrap(err, "error while mark outbox messages sent")
+	}
+
+	return nil
+}
+
+func (o *outboxRepo) IsSent(id int64) (bool, error) {
+
+	var sent bool
+
+	err := o.db.QueryRow(`SELECT sent_at IS NOT NULL FROM "outbox" WHERE id = $1`, id).Scan(&sent)
+	if err != nil {
+		return false, errors.Wrap(err, "error while get outbox message")
+	}
+
+	return sent, nil
+}
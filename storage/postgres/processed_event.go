@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/Invan2/invan_catalog_service/storage/repo"
+	"github.com/pkg/errors"
+)
+
+type processedEventRepo struct {
+	db  models.DB
+	log logger.Logger
+}
+
+func NewProcessedEventRepo(log logger.Logger, db models.DB) repo.ProcessedEventI {
+	return &processedEventRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// MarkProcessed returns false if the event was already processed
+func (p *processedEventRepo) MarkProcessed(id string, topic string) (bool, error) {
+
+	query := `
+		INSERT INTO
+			"processed_event"
+		(
+			id,
+			topic
+		)
+		VALUES (
+			$1,
+			$2
+		) ON CONFLICT (id) DO NOTHING
+	`
+
+	res, err := p.db.Exec(query, id, topic)
+	if err != nil {
+		return false, errors.Wrap(err, "error while insert processed_event")
+	}
+
+	i, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return i > 0, nil
+}
@@ -1079,6 +1079,85 @@ func (p *productRepo) UpsertShopMeasurmentValue(req *catalog_service.UpsertShopM
 	return nil
 }
 
+func (p *productRepo) ApplyStockDeltas(deltas []*models.ShopStockDelta) error {
+
+	var (
+		values  []interface{}
+		merged  = make(map[string]*models.ShopStockDelta)
+		ordered = make([]*models.ShopStockDelta, 0, len(deltas))
+	)
+
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	// one document can contain the same product several times
+	for _, delta := range deltas {
+		key := delta.ProductId + delta.ShopId
+		if v, ok := merged[key]; ok {
+			v.Amount += delta.Amount
+			v.TotalSold += delta.TotalSold
+			v.TotalTransfered += delta.TotalTransfered
+			v.TotalTransferArrived += delta.TotalTransferArrived
+			continue
+		}
+
+		d := *delta
+		merged[key] = &d
+		ordered = append(ordered, &d)
+	}
+
+	query := `
+		INSERT INTO
+			"measurement_values"
+		(
+			product_id,
+			shop_id,
+			amount,
+			total_sold,
+			total_transfered,
+			total_transfer_arrived
+		)
+		SELECT
+			v.product_id,
+			v.shop_id,
+			v.amount,
+			v.total_sold,
+			v.total_transfered,
+			v.total_transfer_arrived
+		FROM (
+			VALUES
+	`
+
+	for _, v := range ordered {
+
+		query += `(?::UUID, ?::UUID, ?::NUMERIC, ?::NUMERIC, ?::NUMERIC, ?::NUMERIC),`
+
+		values = append(values, v.ProductId, v.ShopId, v.Amount, v.TotalSold, v.TotalTransfered, v.TotalTransferArrived)
+	}
+
+	query = strings.TrimSuffix(query, ",")
+
+	query += `
+		) AS v(product_id, shop_id, amount, total_sold, total_transfered, total_transfer_arrived)
+		JOIN "product" p ON p.id = v.product_id
+		ON CONFLICT (product_id, shop_id) DO UPDATE SET
+			amount = measurement_values.amount + EXCLUDED.amount,
+			total_sold = measurement_values.total_sold + EXCLUDED.total_sold,
+			total_transfered = measurement_values.total_transfered + EXCLUDED.total_transfered,
+			total_transfer_arrived = measurement_values.total_transfer_arrived + EXCLUDED.total_transfer_arrived
+	`
+
+	query = helper.ReplaceSQL(query, "?")
+
+	_, err := p.db.Exec(query, values...)
+	if err != nil {
+		return errors.Wrap(err, "error while apply stock deltas")
+	}
+
+	return nil
+}
+
 func (p *productRepo) UpsertShopRetailPrice(req *catalog_service.UpsertShopPriceRequest) error {
 
 	var (
@@ -1162,6 +1241,15 @@ func (p *productRepo) getProductDetailByProductIds(ids []string) (map[string]int
 }
 
 func (p *productRepo) InsertMany(products []*common.CreateProductCopyRequest) error {
+	return p.insertMany(products, false, nil)
+}
+
+// ImportMany keeps amount of existing shops except for products of stockIds, low stock settings are always kept
+func (p *productRepo) ImportMany(products []*common.CreateProductCopyRequest, stockIds []string) error {
+	return p.insertMany(products, true, stockIds)
+}
+
+func (p *productRepo) insertMany(products []*common.CreateProductCopyRequest, imported bool, stockIds []string) error {
 
 	var (
 		values            = []interface{}{}
@@ -1175,6 +1263,15 @@ func (p *productRepo) InsertMany(products []*common.CreateProductCopyRequest) er
 		return nil
 	}
 
+	for _, product := range products {
+		productIds = append(productIds, product.Id)
+	}
+
+	productDetails, err := p.getProductDetailByProductIds(productIds)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO
 			"product"
@@ -1182,34 +1279,31 @@ func (p *productRepo) InsertMany(products []*common.CreateProductCopyRequest) er
 			id,
 			company_id,
 			product_type_id,
-			created_by
+			created_by,
+			last_version
 		)
 		VALUES
 	`
 
 	for _, product := range products {
 
-		query += "(?, ?, ?, ?),"
+		query += "(?, ?, ?, ?, ?),"
 		values = append(values,
 			product.Id,
 			product.Request.CompanyId,
 			product.ProductTypeId,
 			product.Request.UserId,
+			productDetails[product.Id],
 		)
-
-		productIds = append(productIds, product.Id)
 	}
 
 	query = strings.TrimSuffix(query, ",")
 	query = helper.ReplaceSQL(query, "?")
 
+	// existing products get the inserted detail as the last version
 	query += `
-		ON CONFLICT (id) DO NOTHING
+		ON CONFLICT (id) DO UPDATE SET last_version = GREATEST("product".last_version, EXCLUDED.last_version)
 	`
-	productDetails, err := p.getProductDetailByProductIds(productIds)
-	if err != nil {
-		return err
-	}
 
 	stmt, err := p.db.Prepare(query)
 	if err != nil {
@@ -1303,6 +1397,16 @@ func (p *productRepo) InsertMany(products []*common.CreateProductCopyRequest) er
 			brandId.Valid = true
 		}
 
+		if product.SupplierId != "" {
+			supplierId.String = product.SupplierId
+			supplierId.Valid = true
+		}
+
+		if product.VatId != "" {
+			vatId.String = product.VatId
+			vatId.Valid = true
+		}
+
 		queryProductDetails += "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?),"
 		values = append(values,
 			version,
@@ -1400,16 +1504,31 @@ func (p *productRepo) InsertMany(products []*common.CreateProductCopyRequest) er
 	if len(measurementValues) > 0 {
 
 		measurementValuesQuery = strings.TrimSuffix(measurementValuesQuery, ",")
-		measurementValuesQuery = helper.ReplaceSQL(measurementValuesQuery, "?")
 
-		measurementValuesQuery += `
-			ON CONFLICT (product_id, shop_id)
-			DO UPDATE SET
-				is_available = EXCLUDED.is_available,
-				has_trigger = EXCLUDED.has_trigger,
-				amount = EXCLUDED.amount,
-				small_left = EXCLUDED.small_left
-		`
+		if imported {
+			// amount of existing shops may have changed by sales since the import read it
+			measurementValuesQuery += `
+				ON CONFLICT (product_id, shop_id)
+				DO UPDATE SET
+					is_available = EXCLUDED.is_available,
+					amount = CASE
+						WHEN EXCLUDED.product_id = ANY(CAST(? AS UUID[])) THEN EXCLUDED.amount
+						ELSE measurement_values.amount
+					END
+			`
+			measurementValues = append(measurementValues, pq.Array(stockIds))
+		} else {
+			measurementValuesQuery += `
+				ON CONFLICT (product_id, shop_id)
+				DO UPDATE SET
+					is_available = EXCLUDED.is_available,
+					has_trigger = EXCLUDED.has_trigger,
+					amount = EXCLUDED.amount,
+					small_left = EXCLUDED.small_left
+			`
+		}
+
+		measurementValuesQuery = helper.ReplaceSQL(measurementValuesQuery, "?")
 
 		stmt3, err := p.db.Prepare(measurementValuesQuery)
 		if err != nil {
@@ -1694,3 +1813,172 @@ func (p *productRepo) ProductBulkEdit(req *catalog_service.ProductBulkOperationR
 
 	return &common.ResponseID{Id: resposeID}, nil
 }
+
+func replayFilter(req *models.CatalogReplayRequest) (string, map[string]interface{}) {
+
+	var (
+		values = map[string]interface{}{
+			"company_id": req.CompanyId,
+		}
+		filter = ` WHERE p.company_id = :company_id AND p.deleted_at = 0 `
+	)
+
+	if req.UpdatedSince != "" {
+		filter += ` AND (
+			pd.created_at >= :updated_since
+			OR EXISTS (SELECT 1 FROM "measurement_values" umv WHERE umv.product_id = p.id AND umv.updated_at >= :updated_since)
+			OR EXISTS (SELECT 1 FROM "shop_price" usp WHERE usp.product_id = p.id AND usp.updated_at >= :updated_since)
+		) `
+		values["updated_since"] = req.UpdatedSince
+	}
+
+	if len(req.ProductIds) > 0 {
+		filter += ` AND p.id = ANY(CAST(:product_ids AS UUID[])) `
+		values["product_ids"] = pq.Array(req.ProductIds)
+	}
+
+	if req.ShopId != "" {
+		filter += ` AND EXISTS (SELECT 1 FROM "measurement_values" smv WHERE smv.product_id = p.id AND smv.shop_id = :shop_id) `
+		values["shop_id"] = req.ShopId
+	}
+
+	return filter, values
+}
+
+func (p *productRepo) CountForReplay(req *models.CatalogReplayRequest) (int, error) {
+
+	var count int
+
+	filter, values := replayFilter(req)
+
+	query := `
+		SELECT
+			count(p.id)
+		FROM "product" p
+		JOIN "product_detail" pd ON p.id = pd.product_id AND p.last_version = pd.version
+	` + filter
+
+	stmt, err := p.db.PrepareNamed(query)
+	if err != nil {
+		return 0, errors.Wrap(err, "error while prepareName")
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(values).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "error while count products for replay")
+	}
+
+	return count, nil
+}
+
+// GetCopyRequests returns products of the company ordered by id in the shape they are published on create
+func (p *productRepo) GetCopyRequests(req *models.CatalogReplayRequest, afterId string, limit int) ([]*common.CreateProductCopyRequest, error) {
+
+	var (
+		res = make([]*common.CreateProductCopyRequest, 0)
+	)
+
+	filter, values := replayFilter(req)
+	values["limit"] = limit
+
+	if afterId != "" {
+		filter += ` AND p.id > :after_id `
+		values["after_id"] = afterId
+	}
+
+	shopFilter := ""
+	if req.ShopId != "" {
+		shopFilter = ` AND mv.shop_id = :shop_id `
+	}
+
+	query := `
+		SELECT
+			p.id,
+			CAST(p.product_type_id AS VARCHAR),
+			COALESCE(CAST(p.parent_id AS VARCHAR), ''),
+			pd.sku,
+			pd.name,
+			COALESCE(pd.mxik_code, ''),
+			pd.is_marking,
+			COALESCE(CAST(pd.brand_id AS VARCHAR), ''),
+			COALESCE(pd.description, ''),
+			COALESCE(CAST(pd.measurement_unit_id AS VARCHAR), ''),
+			COALESCE(CAST(pd.supplier_id AS VARCHAR), ''),
+			COALESCE(CAST(pd.vat_id AS VARCHAR), ''),
+			COALESCE((SELECT array_agg(pb.barcode) FROM "product_barcode" pb WHERE pb.product_detail_id = pd.id), '{}'),
+			COALESCE((SELECT pi.file_name FROM "product_image" pi WHERE pi.product_detail_id = pd.id ORDER BY pi.sequence_number LIMIT 1), ''),
+			COALESCE((
+				SELECT
+					json_agg(json_build_object(
+						'shop_id', mv.shop_id,
+						'is_available', mv.is_available,
+						'in_stock', mv.amount,
+						'retail_price', COALESCE(sp.retail_price, 0),
+						'supply_price', COALESCE(sp.supply_price, 0),
+						'min_price', COALESCE(sp.min_price, 0),
+						'max_price', COALESCE(sp.max_price, 0),
+						'whole_sale_price', COALESCE(sp.whole_sale_price, 0)
+					))
+				FROM "measurement_values" mv
+				LEFT JOIN "shop_price" sp ON sp.product_id = mv.product_id AND sp.shop_id = mv.shop_id
+				WHERE mv.product_id = p.id ` + shopFilter + `
+			), '[]')
+		FROM "product" p
+		JOIN "product_detail" pd ON p.id = pd.product_id AND p.last_version = pd.version
+	` + filter + `
+		ORDER BY p.id
+		LIMIT :limit
+	`
+
+	rows, err := p.db.NamedQuery(query, values)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get products for replay")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var (
+			product = common.CreateProductCopyRequest{
+				Request: &common.Request{CompanyId: req.CompanyId},
+			}
+			measurementValues []byte
+		)
+
+		err = rows.Scan(
+			&product.Id,
+			&product.ProductTypeId,
+			&product.ParentId,
+			&product.Sku,
+			&product.Name,
+			&product.MxikCode,
+			&product.IsMarking,
+			&product.BrandId,
+			&product.Description,
+			&product.MeasurementUnitId,
+			&product.SupplierId,
+			&product.VatId,
+			pq.Array(&product.Barcode),
+			&product.Image,
+			&measurementValues,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "error while scanning product for replay")
+		}
+
+		if product.Image != "" {
+			product.Image = fmt.Sprintf("https://%s/%s/%s", p.cfg.MinioEndpoint, config.FileBucketName, product.Image)
+		}
+
+		if err := json.Unmarshal(measurementValues, &product.ShopMeasurementValues); err != nil {
+			return nil, errors.Wrap(err, "error while unmarshal measurement values")
+		}
+
+		res = append(res, &product)
+	}
+
+	return res, nil
+}
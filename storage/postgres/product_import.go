@@ -0,0 +1,307 @@
+package postgres
+
+import (
+	"strings"
+
+	"github.com/Invan2/invan_catalog_service/pkg/helper"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// getIdsByNames runs query with company id and lower case names, the query returns name and id
+func (p *productRepo) getIdsByNames(query, companyId string, names []string) (map[string]string, error) {
+
+	var (
+		res   = make(map[string]string)
+		lower = make([]string, 0, len(names))
+	)
+
+	if len(names) == 0 {
+		return res, nil
+	}
+
+	for _, name := range names {
+		lower = append(lower, strings.ToLower(strings.TrimSpace(name)))
+	}
+
+	rows, err := p.db.Query(query, companyId, pq.Array(lower))
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get ids by names")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, id string
+
+		if err := rows.Scan(&name, &id); err != nil {
+			return nil, errors.Wrap(err, "error while scanning id by name")
+		}
+
+		if _, ok := res[name]; !ok {
+			res[name] = id
+		}
+	}
+
+	return res, nil
+}
+
+func (p *productRepo) GetCategoryIdsByNames(companyId string, names []string) (map[string]string, error) {
+
+	query := `
+		SELECT
+			LOWER(name),
+			id
+		FROM "category"
+		WHERE company_id = $1 AND deleted_at = 0 AND LOWER(name) = ANY($2)
+		ORDER BY created_at
+	`
+
+	return p.getIdsByNames(query, companyId, names)
+}
+
+func (p *productRepo) GetBrandIdsByNames(companyId string, names []string) (map[string]string, error) {
+
+	query := `
+		SELECT
+			LOWER(name),
+			id
+		FROM "brand"
+		WHERE company_id = $1 AND deleted_at = 0 AND LOWER(name) = ANY($2)
+		ORDER BY created_at
+	`
+
+	return p.getIdsByNames(query, companyId, names)
+}
+
+func (p *productRepo) GetSupplierIdsByNames(companyId string, names []string) (map[string]string, error) {
+
+	query := `
+		SELECT
+			LOWER(name),
+			id
+		FROM "supplier"
+		WHERE company_id = $1 AND deleted_at = 0 AND LOWER(name) = ANY($2)
+		ORDER BY id
+	`
+
+	return p.getIdsByNames(query, companyId, names)
+}
+
+// GetMeasurementUnitIdsByNames matches both short and long names of the unit
+func (p *productRepo) GetMeasurementUnitIdsByNames(companyId string, names []string) (map[string]string, error) {
+
+	query := `
+		SELECT
+			n.name,
+			mu.id
+		FROM "measurement_unit" mu
+		JOIN "default_measurement_unit" dmu ON dmu.id = mu.unit_id
+		CROSS JOIN LATERAL (VALUES (LOWER(dmu.short_name)), (LOWER(dmu.long_name))) AS n(name)
+		WHERE (mu.company_id = $1 OR mu.company_id IS NULL) AND mu.deleted_at = 0 AND n.name = ANY($2)
+		ORDER BY mu.company_id NULLS LAST, mu.created_at
+	`
+
+	return p.getIdsByNames(query, companyId, names)
+}
+
+// SetCategories sets categories of the last version of the products
+func (p *productRepo) SetCategories(categories map[string][]string) error {
+
+	var values = make([]interface{}, 0)
+
+	query := `
+		INSERT INTO "product_category"
+			("product_detail_id", "category_id")
+		SELECT
+			pd.id,
+			v.category_id
+		FROM (
+			VALUES
+	`
+
+	for productId, categoryIds := range categories {
+		for _, categoryId := range categoryIds {
+			query += `(?::UUID, ?::UUID),`
+			values = append(values, productId, categoryId)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	query = strings.TrimSuffix(query, ",")
+
+	query += `
+		) AS v(product_id, category_id)
+		JOIN "product" p ON p.id = v.product_id
+		JOIN "product_detail" pd ON pd.product_id = p.id AND pd.version = p.last_version
+		ON CONFLICT ("product_detail_id", "category_id") DO NOTHING
+	`
+
+	query = helper.ReplaceSQL(query, "?")
+
+	_, err := p.db.Exec(query, values...)
+	if err != nil {
+		return errors.Wrap(err, "error while set product categories")
+	}
+
+	return nil
+}
+
+// CopyPreviousCategories copies categories of the previous version to the last version of the products
+func (p *productRepo) CopyPreviousCategories(productIds []string) error {
+
+	if len(productIds) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO "product_category"
+			("product_detail_id", "category_id")
+		SELECT
+			pd.id,
+			pc.category_id
+		FROM "product" p
+		JOIN "product_detail" pd ON pd.product_id = p.id AND pd.version = p.last_version
+		JOIN "product_detail" prev ON prev.product_id = p.id AND prev.version = p.last_version - 1
+		JOIN "product_category" pc ON pc.product_detail_id = prev.id
+		WHERE p.id = ANY($1)
+		ON CONFLICT ("product_detail_id", "category_id") DO NOTHING
+	`
+
+	_, err := p.db.Exec(query, pq.Array(productIds))
+	if err != nil {
+		return errors.Wrap(err, "error while copy product categories")
+	}
+
+	return nil
+}
+
+// CopyPreviousImages copies images of the previous version to the last version of the products
+func (p *productRepo) CopyPreviousImages(productIds []string) error {
+
+	if len(productIds) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO "product_image"
+			("id", "sequence_number", "product_detail_id", "file_name")
+		SELECT
+			uuid_generate_v4(),
+			pi.sequence_number,
+			pd.id,
+			pi.file_name
+		FROM "product" p
+		JOIN "product_detail" pd ON pd.product_id = p.id AND pd.version = p.last_version
+		JOIN "product_detail" prev ON prev.product_id = p.id AND prev.version = p.last_version - 1
+		JOIN "product_image" pi ON pi.product_detail_id = prev.id
+		WHERE p.id = ANY($1)
+		ON CONFLICT ("product_detail_id", "sequence_number") DO NOTHING
+	`
+
+	_, err := p.db.Exec(query, pq.Array(productIds))
+	if err != nil {
+		return errors.Wrap(err, "error while copy product images")
+	}
+
+	return nil
+}
+
+// getMatches runs query with company id and values, the query returns value and product id
+func (p *productRepo) getMatches(query, companyId string, values []string) (map[string][]string, error) {
+
+	var res = make(map[string][]string)
+
+	if len(values) == 0 {
+		return res, nil
+	}
+
+	rows, err := p.db.Query(query, companyId, pq.Array(values))
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get matching products")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value, id string
+
+		if err := rows.Scan(&value, &id); err != nil {
+			return nil, errors.Wrap(err, "error while scanning matching product")
+		}
+
+		res[value] = append(res[value], id)
+	}
+
+	return res, nil
+}
+
+// GetIdsBySkus returns ids of products by sku, sku may belong to several products
+func (p *productRepo) GetIdsBySkus(companyId string, skus []string) (map[string][]string, error) {
+
+	query := `
+		SELECT
+			pd.sku,
+			p.id
+		FROM "product" p
+		JOIN "product_detail" pd ON p.id = pd.product_id AND p.last_version = pd.version
+		WHERE p.company_id = $1 AND p.deleted_at = 0 AND pd.sku = ANY($2)
+	`
+
+	return p.getMatches(query, companyId, skus)
+}
+
+// GetIdsByBarcodes returns ids of products by barcode, barcode may belong to several products
+func (p *productRepo) GetIdsByBarcodes(companyId string, barcodes []string) (map[string][]string, error) {
+
+	query := `
+		SELECT
+			pb.barcode,
+			p.id
+		FROM "product" p
+		JOIN "product_detail" pd ON p.id = pd.product_id AND p.last_version = pd.version
+		JOIN "product_barcode" pb ON pb.product_detail_id = pd.id
+		WHERE p.company_id = $1 AND p.deleted_at = 0 AND pb.barcode = ANY($2)
+	`
+
+	return p.getMatches(query, companyId, barcodes)
+}
+
+// GetCategoryIdsByProducts returns category ids of the last version of the products
+func (p *productRepo) GetCategoryIdsByProducts(productIds []string) (map[string][]string, error) {
+
+	var res = make(map[string][]string)
+
+	if len(productIds) == 0 {
+		return res, nil
+	}
+
+	query := `
+		SELECT
+			p.id,
+			pc.category_id
+		FROM "product" p
+		JOIN "product_detail" pd ON p.id = pd.product_id AND p.last_version = pd.version
+		JOIN "product_category" pc ON pc.product_detail_id = pd.id
+		WHERE p.id = ANY($1)
+	`
+
+	rows, err := p.db.Query(query, pq.Array(productIds))
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get product categories")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productId, categoryId string
+
+		if err := rows.Scan(&productId, &categoryId); err != nil {
+			return nil, errors.Wrap(err, "error while scanning product category")
+		}
+
+		res[productId] = append(res[productId], categoryId)
+	}
+
+	return res, nil
+}
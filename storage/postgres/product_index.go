@@ -0,0 +1,410 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"genproto/catalog_service"
+	"time"
+
+	"github.com/Invan2/invan_catalog_service/config"
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+func productIndexFilter(req *models.ProductIndexFilter) (string, map[string]interface{}) {
+
+	var (
+		values = map[string]interface{}{}
+		filter = ` WHERE p.deleted_at = 0 `
+	)
+
+	if req.CompanyId != "" {
+		filter += ` AND p.company_id = :company_id `
+		values["company_id"] = req.CompanyId
+	}
+
+	if req.UpdatedSince != "" {
+		filter += ` AND (
+			pd.created_at >= :updated_since
+			OR EXISTS (SELECT 1 FROM "measurement_values" umv WHERE umv.product_id = p.id AND umv.updated_at >= :updated_since)
+			OR EXISTS (SELECT 1 FROM "shop_price" usp WHERE usp.product_id = p.id AND usp.updated_at >= :updated_since)
+		) `
+		values["updated_since"] = req.UpdatedSince
+	}
+
+	if len(req.ProductIds) > 0 {
+		filter += ` AND p.id = ANY(CAST(:product_ids AS UUID[])) `
+		values["product_ids"] = pq.Array(req.ProductIds)
+	}
+
+	return filter, values
+}
+
+func (p *productRepo) CountForIndex(req *models.ProductIndexFilter) (int, error) {
+
+	var count int
+
+	filter, values := productIndexFilter(req)
+
+	query := `
+		SELECT
+			count(p.id)
+		FROM "product" p
+		JOIN "product_detail" pd ON p.id = pd.product_id AND p.last_version = pd.version
+	` + filter
+
+	stmt, err := p.db.PrepareNamed(query)
+	if err != nil {
+		return 0, errors.Wrap(err, "error while prepareName")
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(values).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "error while count products for index")
+	}
+
+	return count, nil
+}
+
+// GetDeletedIdsForIndex returns ids of products deleted since UpdatedSince
+func (p *productRepo) GetDeletedIdsForIndex(req *models.ProductIndexFilter) ([]string, error) {
+
+	var res = make([]string, 0)
+
+	query := `
+		SELECT
+			id
+		FROM "product"
+		WHERE deleted_at >= extract(epoch from CAST($1 AS TIMESTAMP) AT TIME ZONE current_setting('TimeZone'))::bigint
+			AND ($2 = '' OR company_id::VARCHAR = $2)
+	`
+
+	rows, err := p.db.Query(query, req.UpdatedSince, req.CompanyId)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get deleted products")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, "error while scanning product id")
+		}
+
+		res = append(res, id)
+	}
+
+	return res, nil
+}
+
+func (p *productRepo) IndexCheckpoint() (string, error) {
+
+	var checkpoint string
+
+	query := `
+		SELECT
+			to_char(COALESCE(min(xact_start), now())::TIMESTAMP, 'YYYY-MM-DD HH24:MI:SS')
+		FROM pg_stat_activity
+		WHERE datname = current_database()
+	`
+
+	err := p.db.QueryRow(query).Scan(&checkpoint)
+	if err != nil {
+		return "", errors.Wrap(err, "error while get index checkpoint")
+	}
+
+	return checkpoint, nil
+}
+
+func (p *productRepo) LockForIndex() error {
+
+	_, err := p.db.Exec(`LOCK TABLE "product", "product_detail", "measurement_values", "shop_price" IN SHARE MODE`)
+	if err != nil {
+		return errors.Wrap(err, "error while lock products for index")
+	}
+
+	return nil
+}
+
+// GetForIndex returns products in the shape they are stored on elastic ordered by id
+func (p *productRepo) GetForIndex(req *models.ProductIndexFilter, afterId string, limit int) ([]*catalog_service.ProductES, error) {
+
+	var (
+		res = make([]*catalog_service.ProductES, 0)
+	)
+
+	filter, values := productIndexFilter(req)
+	values["limit"] = limit
+
+	if afterId != "" {
+		filter += ` AND p.id > :after_id `
+		values["after_id"] = afterId
+	}
+
+	query := `
+		SELECT
+			p.id,
+			CAST(p.company_id AS VARCHAR),
+			CAST(p.product_type_id AS VARCHAR),
+			COALESCE(CAST(p.parent_id AS VARCHAR), ''),
+			p.created_at,
+			pd.created_at,
+			pd.sku,
+			pd.name,
+			COALESCE(pd.mxik_code, ''),
+			pd.is_marking,
+			COALESCE(pd.description, ''),
+			COALESCE((SELECT array_agg(pb.barcode) FROM "product_barcode" pb WHERE pb.product_detail_id = pd.id), '{}'),
+			COALESCE((SELECT pi.file_name FROM "product_image" pi WHERE pi.product_detail_id = pd.id ORDER BY pi.sequence_number LIMIT 1), ''),
+			CASE WHEN mu.id IS NULL THEN NULL ELSE json_build_object(
+				'id', mu.id,
+				'short_name', dmu.short_name,
+				'long_name', dmu.long_name,
+				'is_deletable', mu.is_deletable,
+				'short_name_translation', dmu.short_name_translation,
+				'long_name_translation', dmu.long_name_translation,
+				'precision', json_build_object('id', mp.id, 'value', mp.value)
+			) END,
+			CASE WHEN s.id IS NULL THEN NULL ELSE json_build_object('id', s.id, 'name', s.name) END,
+			CASE WHEN v.id IS NULL THEN NULL ELSE json_build_object('id', v.id, 'name', v.name, 'percentage', v.percentage) END,
+			CASE WHEN br.id IS NULL THEN NULL ELSE json_build_object('id', br.id, 'name', br.name) END,
+			COALESCE((
+				SELECT
+					json_agg(json_build_object('id', t.id, 'name', t.name))
+				FROM "product_tag" pt
+				JOIN "tag" t ON t.id = pt.tag_id AND t.deleted_at = 0
+				WHERE pt.product_detail_id = pd.id
+			), '[]'),
+			COALESCE((
+				SELECT
+					json_agg(json_build_object('id', c.id, 'name', c.name, 'parent_id', COALESCE(CAST(c.parent_id AS VARCHAR), '')))
+				FROM "product_category" pc
+				JOIN "category" c ON c.id = pc.category_id AND c.deleted_at = 0
+				WHERE pc.product_detail_id = pd.id
+			), '[]'),
+			COALESCE((
+				SELECT
+					json_agg(json_build_object(
+						'shop_id', mv.shop_id,
+						'shop_name', COALESCE(sh.name, ''),
+						'amount', mv.amount,
+						'small_left', mv.small_left,
+						'has_trigger', mv.has_trigger,
+						'is_available', mv.is_available
+					))
+				FROM "measurement_values" mv
+				LEFT JOIN "shop" sh ON sh.id = mv.shop_id AND sh.deleted_at = 0
+				WHERE mv.product_id = p.id
+			), '[]'),
+			COALESCE((
+				SELECT
+					json_agg(json_build_object(
+						'shop_id', sp.shop_id,
+						'shop_name', COALESCE(sh.name, ''),
+						'retail_price', sp.retail_price,
+						'supply_price', sp.supply_price,
+						'min_price', sp.min_price,
+						'max_price', sp.max_price,
+						'whole_sale_price', sp.whole_sale_price
+					))
+				FROM "shop_price" sp
+				LEFT JOIN "shop" sh ON sh.id = sp.shop_id AND sh.deleted_at = 0
+				WHERE sp.product_id = p.id
+			), '[]')
+		FROM "product" p
+		JOIN "product_detail" pd ON p.id = pd.product_id AND p.last_version = pd.version
+		LEFT JOIN "supplier" s ON s.id = pd.supplier_id AND s.deleted_at = 0
+		LEFT JOIN "vat" v ON v.id = pd.vat_id AND v.deleted_at = 0
+		LEFT JOIN "brand" br ON br.id = pd.brand_id AND br.deleted_at = 0
+		LEFT JOIN "measurement_unit" mu ON mu.id = pd.measurement_unit_id
+		LEFT JOIN "default_measurement_unit" dmu ON mu.unit_id = dmu.id
+		LEFT JOIN "measurement_precision" mp ON mp.id = mu.precision_id
+	` + filter + `
+		ORDER BY p.id
+		LIMIT :limit
+	`
+
+	stmt, err := p.db.PrepareNamed(query)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while prepareName")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Queryx(values)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get products for index")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var (
+			product = catalog_service.ProductES{
+				MeasurementValues: make(map[string]*catalog_service.ShopMeasurementValue),
+				ShopPrices:        make(map[string]*catalog_service.ShopPrice),
+			}
+			createdAt         time.Time
+			updatedAt         time.Time
+			measurementUnit   []byte
+			supplier          []byte
+			vat               []byte
+			brand             []byte
+			tags              []byte
+			categories        []byte
+			measurementValues []byte
+			shopPrices        []byte
+		)
+
+		err = rows.Scan(
+			&product.Id,
+			&product.CompanyId,
+			&product.ProductTypeId,
+			&product.ParentId,
+			&createdAt,
+			&updatedAt,
+			&product.Sku,
+			&product.Name,
+			&product.MxikCode,
+			&product.IsMarking,
+			&product.Description,
+			pq.Array(&product.Barcodes),
+			&product.Image,
+			&measurementUnit,
+			&supplier,
+			&vat,
+			&brand,
+			&tags,
+			&categories,
+			&measurementValues,
+			&shopPrices,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "error while scanning product for index")
+		}
+
+		product.CreatedAt = createdAt.Format(config.DateTimeFormat)
+		product.UpdatedAt = float64(updatedAt.UnixMilli())
+
+		if err := unmarshalIfNotNull(measurementUnit, &product.MeasurementUnit); err != nil {
+			return nil, errors.Wrap(err, "error while unmarshal measurement unit")
+		}
+
+		if err := unmarshalIfNotNull(supplier, &product.Supplier); err != nil {
+			return nil, errors.Wrap(err, "error while unmarshal supplier")
+		}
+
+		if err := unmarshalIfNotNull(vat, &product.Vat); err != nil {
+			return nil, errors.Wrap(err, "error while unmarshal vat")
+		}
+
+		if err := unmarshalIfNotNull(brand, &product.Brand); err != nil {
+			return nil, errors.Wrap(err, "error while unmarshal brand")
+		}
+
+		if err := json.Unmarshal(tags, &product.Tags); err != nil {
+			return nil, errors.Wrap(err, "error while unmarshal tags")
+		}
+
+		if err := json.Unmarshal(categories, &product.Categories); err != nil {
+			return nil, errors.Wrap(err, "error while unmarshal categories")
+		}
+
+		var values []*catalog_service.ShopMeasurementValue
+		if err := json.Unmarshal(measurementValues, &values); err != nil {
+			return nil, errors.Wrap(err, "error while unmarshal measurement values")
+		}
+
+		for _, value := range values {
+			product.MeasurementValues[value.ShopId] = value
+		}
+
+		var prices []*catalog_service.ShopPrice
+		if err := json.Unmarshal(shopPrices, &prices); err != nil {
+			return nil, errors.Wrap(err, "error while unmarshal shop prices")
+		}
+
+		for _, price := range prices {
+			product.ShopPrices[price.ShopId] = price
+		}
+
+		res = append(res, &product)
+	}
+
+	return res, nil
+}
+
+func unmarshalIfNotNull(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func (p *productRepo) GetShortBrand(id string) (*catalog_service.ShortBrand, error) {
+
+	if id == "" {
+		return nil, nil
+	}
+
+	var brand catalog_service.ShortBrand
+
+	query := `
+		SELECT
+			br.id,
+			br.name
+		FROM "brand" br
+		WHERE br.id = $1 AND br.deleted_at = 0
+	`
+
+	err := p.db.QueryRow(query, id).Scan(&brand.Id, &brand.Name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get short brand")
+	}
+
+	return &brand, nil
+}
+
+func (p *productRepo) GetShortTagsByIds(ids []string) ([]*catalog_service.ShortTag, error) {
+
+	var res = make([]*catalog_service.ShortTag, 0)
+
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	query := `
+		SELECT
+			t.id,
+			t.name
+		FROM "tag" t
+		WHERE t.id = ANY($1) AND t.deleted_at = 0
+	`
+
+	rows, err := p.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, errors.Wrap(err, "error while get short tags")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag catalog_service.ShortTag
+
+		if err := rows.Scan(&tag.Id, &tag.Name); err != nil {
+			return nil, errors.Wrap(err, "error while scanning tag")
+		}
+
+		res = append(res, &tag)
+	}
+
+	return res, nil
+}
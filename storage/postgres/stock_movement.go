@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"github.com/Invan2/invan_catalog_service/models"
+	"github.com/Invan2/invan_catalog_service/pkg/logger"
+	"github.com/Invan2/invan_catalog_service/storage/repo"
+	"github.com/pkg/errors"
+)
+
+type stockMovementRepo struct {
+	db  models.DB
+	log logger.Logger
+}
+
+func NewStockMovementRepo(log logger.Logger, db models.DB) repo.StockMovementI {
+	return &stockMovementRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Register returns false if the movement of the document was already applied
+func (s *stockMovementRepo) Register(documentId string, movementType string) (bool, error) {
+
+	query := `
+		INSERT INTO
+			"stock_movement"
+		(
+			document_id,
+			type
+		)
+		VALUES (
+			$1,
+			$2
+		) ON CONFLICT (document_id, type) DO NOTHING
+	`
+
+	res, err := s.db.Exec(query, documentId, movementType)
+	if err != nil {
+		return false, errors.Wrap(err, "error while insert stock_movement")
+	}
+
+	i, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return i > 0, nil
+}
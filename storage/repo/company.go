@@ -7,4 +7,5 @@ import (
 type CompanyPgI interface {
 	Upsert(entity *common.CompanyCreatedModel) error
 	Delete(req *common.RequestID) (*common.ResponseID, error)
+	GetIds() ([]string, error)
 }
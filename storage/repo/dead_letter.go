@@ -0,0 +1,10 @@
+package repo
+
+import "github.com/Invan2/invan_catalog_service/models"
+
+type DeadLetterI interface {
+	Create(letter *models.DeadLetter) error
+	GetAll(req *models.GetDeadLettersRequest) (*models.GetDeadLettersResponse, error)
+	GetByIds(ids []string) ([]*models.DeadLetter, error)
+	MarkReplayed(id string) error
+}
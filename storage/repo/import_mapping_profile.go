@@ -0,0 +1,11 @@
+package repo
+
+import "github.com/Invan2/invan_catalog_service/models"
+
+type ImportMappingProfileI interface {
+	Create(profile *models.ImportMappingProfile) error
+	Update(profile *models.ImportMappingProfile) error
+	GetById(id, companyId string) (*models.ImportMappingProfile, error)
+	GetAll(companyId string) ([]*models.ImportMappingProfile, error)
+	Delete(id, companyId string) error
+}
@@ -0,0 +1,20 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/Invan2/invan_catalog_service/models"
+)
+
+type JobI interface {
+	Create(job *models.Job) error
+	GetById(id, companyId string) (*models.Job, error)
+	GetAll(req *models.GetJobsRequest) (*models.GetJobsResponse, error)
+	// Claim marks the oldest pending or stale running job as running, nil if there is no job
+	Claim(staleTimeout time.Duration) (*models.Job, error)
+	// UpdateProgress also serves as heartbeat of the running job
+	UpdateProgress(id string, progress int) (cancelRequested bool, err error)
+	Finish(id, status, resultUrl, errMessage string) error
+	// Cancel cancels pending job at once, running job is cancelled by its worker
+	Cancel(id, companyId string) (*models.Job, error)
+}
@@ -0,0 +1,12 @@
+package repo
+
+import "github.com/Invan2/invan_catalog_service/models"
+
+type OutboxI interface {
+	Create(topic string, key string, payload interface{}) (int64, error)
+	// Lock takes transaction level lock, so that only one relay publishes messages
+	Lock() (bool, error)
+	GetNotSent(limit int) ([]*models.OutboxMessage, error)
+	MarkSent(ids []int64) error
+	IsSent(id int64) (bool, error)
+}
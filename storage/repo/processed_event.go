@@ -0,0 +1,5 @@
+package repo
+
+type ProcessedEventI interface {
+	MarkProcessed(id string, topic string) (bool, error)
+}
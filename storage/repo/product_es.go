@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"context"
 	"genproto/catalog_service"
 	"genproto/common"
 
@@ -13,12 +14,15 @@ type ProductESI interface {
 	Update(product *catalog_service.ProductES) error
 	UpsertShopMeasurmentValue(supplierOrder *catalog_service.UpsertShopMeasurmentValueRequest) error
 	GetAll(req *catalog_service.GetAllProductsRequest) (*catalog_service.GetAllProductsResponse, error)
-	GetForLabel(req *catalog_service.GetProductLabelsRequest) (*catalog_service.GetAllProductsResponse, error)
+	GetAllByCursor(ctx context.Context, req *catalog_service.GetAllProductsByCursorRequest) (*catalog_service.GetAllProductsByCursorResponse, error)
+	ScanAll(ctx context.Context, req *catalog_service.GetAllProductsRequest, fn func(products []*catalog_service.ProductES, total int64) error) error
+	ScanForLabel(ctx context.Context, req *catalog_service.GetProductLabelsRequest, fn func([]*catalog_service.ProductES) error) error
 	SearchProducts(entity *catalog_service.GetAllProductsRequest) (*catalog_service.SearchProductsResponse, error)
+	SuggestProducts(req *catalog_service.SuggestProductsRequest) (*catalog_service.SuggestProductsResponse, error)
+	GetValuation(req *catalog_service.GetValuationRequest) (*catalog_service.GetValuationResponse, error)
 	DeleteProduct(*common.RequestID) (*common.Empty, error)
 	DeleteProducts(*common.RequestIDs) (*common.Empty, error)
-	GetAllForExcel(req *catalog_service.GetAllProductsRequest) (*models.GetAllForExcelResponse, error)
-	GetAllForCSV(req *catalog_service.GetAllProductsRequest) (*models.GetAllForCsvResponse, error)
+	ApplyStockDeltas(deltas []*models.ShopStockDelta) error
 	UpsertShopPrice(req *catalog_service.UpsertShopPriceRequest) error
 	BulkUpdateProduct(req *catalog_service.ProductBulkOperationRequest, productMap map[string]*catalog_service.ProductES) error
 }
@@ -0,0 +1,21 @@
+package repo
+
+import (
+	"genproto/catalog_service"
+
+	"github.com/Invan2/invan_catalog_service/models"
+)
+
+type ProductIndexESI interface {
+	EnsureTemplate() error
+	CheckMapping(alias string) error
+	CreateIndex(name string) error
+	DeleteIndex(name string) error
+	BulkIndex(index string, products []*catalog_service.ProductES) error
+	Count(index, companyId string) (int, error)
+	GetIds(index, companyId string) ([]string, error)
+	GetByIds(index string, ids []string) (map[string]*models.ProductDocument, error)
+	BulkIndexIfUnchanged(index string, products []*catalog_service.ProductES, documents map[string]*models.ProductDocument) ([]string, error)
+	DeleteIds(index string, ids []string) error
+	SwapAlias(alias, index string, keepOld bool) ([]string, error)
+}
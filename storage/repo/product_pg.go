@@ -10,6 +10,8 @@ import (
 type ProductPgI interface {
 	Create(entity *catalog_service.CreateProductRequest) (productId string, productDetailId string, err error)
 	InsertMany([]*common.CreateProductCopyRequest) error
+	// ImportMany is InsertMany of the import, amount of existing shops is set only for products of stockIds
+	ImportMany(products []*common.CreateProductCopyRequest, stockIds []string) error
 	GetByID(req *common.RequestID) (*catalog_service.Product, error)
 	Update(entity *catalog_service.UpdateProductRequest) (*common.ResponseID, error)
 	UpsertShopMeasurmentValue(req *catalog_service.UpsertShopMeasurmentValueRequest) error
@@ -17,6 +19,29 @@ type ProductPgI interface {
 	Delete(req *common.RequestID) (*common.ResponseID, error)
 	DeleteProducts(entity *common.RequestIDs) (*common.Empty, error)
 	GetProductCustomFields(req *common.Request) ([]*models.GetProductCustomFieldResponse, error)
+	ApplyStockDeltas(deltas []*models.ShopStockDelta) error
 	UpsertShopRetailPrice(req *catalog_service.UpsertShopPriceRequest) error
 	ProductBulkEdit(req *catalog_service.ProductBulkOperationRequest) (*common.ResponseID, error)
+	GetCopyRequests(req *models.CatalogReplayRequest, afterId string, limit int) ([]*common.CreateProductCopyRequest, error)
+	CountForReplay(req *models.CatalogReplayRequest) (int, error)
+	GetForIndex(req *models.ProductIndexFilter, afterId string, limit int) ([]*catalog_service.ProductES, error)
+	CountForIndex(req *models.ProductIndexFilter) (int, error)
+	GetDeletedIdsForIndex(req *models.ProductIndexFilter) ([]string, error)
+	// IndexCheckpoint returns the start of the oldest running transaction, changes committed later are updated since it
+	IndexCheckpoint() (string, error)
+	// LockForIndex blocks product, stock and price writers till the end of transaction
+	LockForIndex() error
+	GetShortBrand(id string) (*catalog_service.ShortBrand, error)
+	GetShortTagsByIds(ids []string) ([]*catalog_service.ShortTag, error)
+	// lookups used by import return ids by lower case names
+	GetCategoryIdsByNames(companyId string, names []string) (map[string]string, error)
+	GetBrandIdsByNames(companyId string, names []string) (map[string]string, error)
+	GetSupplierIdsByNames(companyId string, names []string) (map[string]string, error)
+	GetMeasurementUnitIdsByNames(companyId string, names []string) (map[string]string, error)
+	GetIdsBySkus(companyId string, skus []string) (map[string][]string, error)
+	GetIdsByBarcodes(companyId string, barcodes []string) (map[string][]string, error)
+	GetCategoryIdsByProducts(productIds []string) (map[string][]string, error)
+	SetCategories(categories map[string][]string) error
+	CopyPreviousCategories(productIds []string) error
+	CopyPreviousImages(productIds []string) error
 }
@@ -0,0 +1,5 @@
+package repo
+
+type StockMovementI interface {
+	Register(documentId string, movementType string) (bool, error)
+}